@@ -10,6 +10,7 @@ import (
 	"zm/internal/config"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var configCmd = &cobra.Command{
@@ -63,8 +64,25 @@ func runConfigSetup(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("protocol must be 'zosmf' or 'ftp'")
 	}
 
-	// Port (default depends on protocol)
+	// FTPS, or the trust of the z/OSMF certificate
+	var tlsSettings *config.TLS
 	defaultPort := strconv.Itoa(config.DefaultPortForProtocol(protocol))
+	if protocol == "ftp" {
+		tlsSettings = &config.TLS{Mode: prompt(reader, "TLS (none/explicit/implicit)", "explicit")}
+		if err := tlsSettings.Validate(); err != nil {
+			return err
+		}
+		switch tlsSettings.Mode {
+		case "none":
+			tlsSettings = nil
+		case "implicit":
+			defaultPort = "990"
+		}
+	} else if ca := prompt(reader, "CA bundle of the z/OSMF certificate (empty: system roots)", ""); ca != "" {
+		tlsSettings = &config.TLS{CAFile: ca}
+	}
+
+	// Port (default depends on protocol)
 	portStr := prompt(reader, "Port", defaultPort)
 	port, err := strconv.Atoi(portStr)
 	if err != nil {
@@ -86,6 +104,7 @@ func runConfigSetup(cmd *cobra.Command, args []string) error {
 		Protocol: protocol,
 		HLQ:      hlq,
 		USSHome:  ussHome,
+		TLS:      tlsSettings,
 	}
 
 	// Load existing config or create new
@@ -144,10 +163,15 @@ func prompt(reader *bufio.Reader, label, defaultVal string) string {
 	return input
 }
 
+// promptPassword reads a password without echoing it when stdin is a
+// terminal.
 func promptPassword(reader *bufio.Reader, label string) string {
 	fmt.Printf("%s: ", label)
-	// Note: In a real implementation, we'd use term.ReadPassword for hidden input
-	// For now, just read normally (password will be visible)
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		input, _ := term.ReadPassword(fd)
+		fmt.Println()
+		return strings.TrimSpace(string(input))
+	}
 	input, _ := reader.ReadString('\n')
 	return strings.TrimSpace(input)
 }
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"zm/internal/connection"
+	"zm/internal/explain"
+)
+
+// loadCatalog returns the built-in explanations extended by the catalog file
+// named in the config.
+func loadCatalog() (*explain.Catalog, error) {
+	path := ""
+	if cfg != nil {
+		var err error
+		if path, err = cfg.CatalogPath(); err != nil {
+			return nil, err
+		}
+	}
+	return explain.Load(path)
+}
+
+// explainRetCode appends a short explanation to an abend return code.
+func explainRetCode(cat *explain.Catalog, rc string) string {
+	if e, ok := cat.Lookup(rc); ok {
+		return fmt.Sprintf("%s (%s)", rc, e.Summary)
+	}
+	return rc
+}
+
+// explainFailure prints what the return code of a failed job means and
+// explains the catalogued messages in its output. Nothing is printed for
+// jobs that ended with CC 4 or less.
+func explainFailure(conn connection.Connection, cat *explain.Catalog, jobid, rc string) {
+	if !connection.MatchRetCode(rc, "failed") {
+		return
+	}
+
+	if e, ok := cat.Lookup(rc); ok {
+		fmt.Printf("\n%s: %s\n  %s\n", rc, e.Summary, e.Cause)
+	}
+
+	output, err := conn.GetJobOutput(jobid)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot read output of %s: %v\n", jobid, err)
+		return
+	}
+	msgs := cat.Messages(output)
+	if len(msgs) == 0 {
+		return
+	}
+	fmt.Println("\nMessages:")
+	for _, m := range msgs {
+		fmt.Printf("  %-9s %s\n            %s\n", m.ID, m.Summary, m.Cause)
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"zm/internal/connection"
+	"zm/internal/spool"
+
+	"github.com/spf13/cobra"
+)
+
+// grepWorkers bounds the number of jobs whose spool is searched at once.
+const grepWorkers = 4
+
+var (
+	grepIgnoreCase bool
+	grepDD         string
+)
+
+var jobsGrepCmd = &cobra.Command{
+	Use:   "grep <pattern> [jobid]",
+	Short: "Search job spool output",
+	Long: `Search the spool files of a job, or of every job matching the list filters,
+for a regular expression. Each match is printed as JOBID:STEP.DDNAME:LINE: text.
+
+Examples:
+  zm jobs grep IGZ0035S JOB01234
+  zm jobs grep 'IEC141I|IEC130I' --since 12h --owner '*'
+  zm jobs grep -i abend --prefix PAY --rc failed`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runJobsGrep,
+}
+
+func init() {
+	jobsCmd.AddCommand(jobsGrepCmd)
+	jobsGrepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "ignore case when matching")
+	jobsGrepCmd.Flags().StringVar(&grepDD, "dd", "", "only search spool files with this DD name")
+	jobsGrepCmd.Flags().StringVar(&jobsOwner, "owner", "", "filter by owner (default: current user, use '*' for all)")
+	addJobFilterFlags(jobsGrepCmd)
+}
+
+// jobMatches holds the matches found in the spool of one job.
+type jobMatches struct {
+	files   []connection.SpoolFile
+	matches [][]spool.Match
+	err     error
+}
+
+func runJobsGrep(cmd *cobra.Command, args []string) error {
+	pattern := args[0]
+	if grepIgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	_, conn, err := openConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var jobids []string
+	if len(args) > 1 {
+		jobids = []string{args[1]}
+	} else {
+		filter, err := currentJobFilter()
+		if err != nil {
+			return err
+		}
+		jobs, err := conn.ListJobs(filter)
+		if err != nil {
+			return err
+		}
+		for _, j := range jobs {
+			// Jobs still in the input queue have no spool to search.
+			if j.Status != "INPUT" {
+				jobids = append(jobids, j.JobID)
+			}
+		}
+	}
+
+	if len(jobids) == 0 {
+		fmt.Println("No jobs found")
+		return nil
+	}
+
+	results := grepJobs(conn, jobids, re)
+
+	found, failed := 0, 0
+	for i, r := range results {
+		if r.err != nil {
+			// Purged or unreadable jobs do not stop the search of the others
+			if len(jobids) > 1 {
+				fmt.Fprintf(os.Stderr, "Warning: failed to search %s: %v\n", jobids[i], r.err)
+			}
+			failed++
+			continue
+		}
+		for j, f := range r.files {
+			for _, m := range r.matches[j] {
+				fmt.Printf("%s:%s.%s:%d: %s\n", jobids[i], f.StepName, f.DDName, m.Line, m.Text)
+				found++
+			}
+		}
+	}
+
+	if failed == len(jobids) {
+		if failed == 1 {
+			return fmt.Errorf("failed to search %s: %w", jobids[0], results[0].err)
+		}
+		return fmt.Errorf("failed to search all %d jobs", failed)
+	}
+	if found == 0 {
+		fmt.Printf("No matches in %d job(s)\n", len(jobids)-failed)
+	}
+	return nil
+}
+
+// grepJobs searches the spool of each job, a few jobs at a time, and returns
+// the results in the order of jobids.
+func grepJobs(conn connection.Connection, jobids []string, re *regexp.Regexp) []jobMatches {
+	results := make([]jobMatches, len(jobids))
+	sem := make(chan struct{}, grepWorkers)
+	var wg sync.WaitGroup
+
+	for i, jobid := range jobids {
+		wg.Add(1)
+		go func(idx int, jobid string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[idx] = grepJob(conn, jobid, re)
+		}(i, jobid)
+	}
+	wg.Wait()
+	return results
+}
+
+func grepJob(conn connection.Connection, jobid string, re *regexp.Regexp) jobMatches {
+	files, err := conn.ListSpoolFiles(jobid)
+	if err != nil {
+		return jobMatches{err: err}
+	}
+
+	var r jobMatches
+	for _, f := range files {
+		if grepDD != "" && !strings.EqualFold(f.DDName, grepDD) {
+			continue
+		}
+		data, err := conn.ReadSpoolFile(jobid, f.ID)
+		if err != nil {
+			return jobMatches{err: fmt.Errorf("failed to read DD %s: %w", f.DDName, err)}
+		}
+		if m := spool.Grep(data, re); len(m) > 0 {
+			r.files = append(r.files, f)
+			r.matches = append(r.matches, m)
+		}
+	}
+	return r
+}
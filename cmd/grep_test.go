@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"zm/internal/connection"
+)
+
+// spoolStub serves spool files from memory, keyed by job ID.
+type spoolStub struct {
+	connection.Connection
+	spool map[string][]string
+}
+
+func (s *spoolStub) ListSpoolFiles(jobid string) ([]connection.SpoolFile, error) {
+	files, ok := s.spool[jobid]
+	if !ok {
+		return nil, fmt.Errorf("job %s not found", jobid)
+	}
+	out := make([]connection.SpoolFile, len(files))
+	for i := range files {
+		out[i] = connection.SpoolFile{ID: i + 1, StepName: "STEP1", DDName: fmt.Sprintf("DD%d", i+1)}
+	}
+	return out, nil
+}
+
+func (s *spoolStub) ReadSpoolFile(jobid string, id int) ([]byte, error) {
+	return []byte(s.spool[jobid][id-1]), nil
+}
+
+func TestGrepJobs(t *testing.T) {
+	conn := &spoolStub{spool: map[string][]string{
+		"JOB00001": {"nothing here\n", "line one\nIGZ0035S open failed\n"},
+		"JOB00002": {"clean run\n"},
+		"JOB00003": {"IGZ0035S first\n", "IGZ0035S second\n"},
+	}}
+
+	results := grepJobs(conn, []string{"JOB00001", "JOB00002", "JOB00003"}, regexp.MustCompile("IGZ0035S"))
+
+	if len(results[0].files) != 1 || results[0].files[0].DDName != "DD2" || results[0].matches[0][0].Line != 2 {
+		t.Errorf("JOB00001 = %+v", results[0])
+	}
+	if len(results[1].files) != 0 {
+		t.Errorf("JOB00002 should have no matches, got %+v", results[1])
+	}
+	if len(results[2].files) != 2 {
+		t.Errorf("JOB00003 should match in 2 files, got %+v", results[2])
+	}
+
+	results = grepJobs(conn, []string{"JOB99999"}, regexp.MustCompile("X"))
+	if results[0].err == nil {
+		t.Error("expected error for unknown job")
+	}
+}
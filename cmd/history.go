@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"zm/internal/connection"
+	"zm/internal/history"
+	"zm/internal/jcl"
+	"zm/internal/spool"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyAll     bool
+	historyPrefix  string
+	historySource  string
+	historyRC      string
+	historySince   string
+	historyMax     int
+	historyPending bool
+	historyRefresh bool
+	historyJCL     bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the local history of submitted jobs",
+}
+
+var historyJobsCmd = &cobra.Command{
+	Use:   "jobs [jobid]",
+	Short: "List jobs submitted with zm, or show one of them",
+	Long: `List the jobs submitted with zm from this machine, newest first, or show
+the recorded details of one job, including its step return codes and JCL.
+
+The history is kept in ~/.zm/history.db and survives JES purging the output.
+Completion is recorded by submit --wait and zm run; use --refresh to fetch the
+result of jobs submitted without waiting.
+
+Examples:
+  zm history jobs --prefix PAY --rc '<=4' --max 1
+  zm history jobs --source 'MY.JCL(NIGHTLY)' --since 168h
+  zm history jobs JOB01234 --jcl`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHistoryJobs,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyJobsCmd)
+	historyJobsCmd.Flags().BoolVar(&historyAll, "all", false, "include jobs of every profile")
+	historyJobsCmd.Flags().StringVar(&historyPrefix, "prefix", "", "filter by job name prefix")
+	historyJobsCmd.Flags().StringVar(&historySource, "source", "", "filter by source (substring, e.g. a member name)")
+	historyJobsCmd.Flags().StringVar(&historyRC, "rc", "", "filter by return code: failed, abend or a comparison like '>4'")
+	historyJobsCmd.Flags().StringVar(&historySince, "since", "", "only jobs submitted since a date (YYYY-MM-DD) or duration ago (e.g. 24h)")
+	historyJobsCmd.Flags().IntVar(&historyMax, "max", 50, "maximum number of jobs to list (0 for all)")
+	historyJobsCmd.Flags().BoolVar(&historyPending, "pending", false, "only jobs whose completion was not recorded")
+	historyJobsCmd.Flags().BoolVar(&historyRefresh, "refresh", false, "fetch the result of pending jobs from JES first")
+	historyJobsCmd.Flags().BoolVar(&historyJCL, "jcl", false, "print the recorded JCL (requires jobid)")
+}
+
+func runHistoryJobs(cmd *cobra.Command, args []string) error {
+	db, err := openHistory()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if historyRefresh {
+		if err := refreshHistory(db); err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 0 {
+		rec, err := db.Get(cfg.DefaultProfile, args[0])
+		if err != nil {
+			return err
+		}
+		if historyJCL {
+			if rec.JCL == "" {
+				return fmt.Errorf("no JCL recorded for %s", rec.JobID)
+			}
+			fmt.Print(rec.JCL)
+			return nil
+		}
+		printHistoryDetail(rec)
+		return nil
+	}
+
+	if historyJCL {
+		return fmt.Errorf("--jcl requires a jobid")
+	}
+	if historyRC != "" {
+		f := connection.JobFilter{RetCode: historyRC}
+		if err := f.Validate(); err != nil {
+			return err
+		}
+	}
+
+	q := history.Query{
+		JobName: historyPrefix,
+		Source:  historySource,
+		RetCode: historyRC,
+		Pending: historyPending,
+		Limit:   historyMax,
+	}
+	if !historyAll {
+		q.Profile = cfg.DefaultProfile
+	}
+	if historySince != "" {
+		if q.Since, err = parseSince(historySince, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	records, err := db.List(q)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Println("No jobs found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SUBMITTED\tJOBID\tJOBNAME\tPROFILE\tSOURCE\tRC\tELAPSED")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Submitted.Local().Format(time.DateTime), r.JobID, r.JobName, r.Profile, r.Source, r.RetCode, elapsed(r))
+	}
+	w.Flush()
+	return nil
+}
+
+func printHistoryDetail(r *history.Record) {
+	fmt.Printf("Job ID:    %s\n", r.JobID)
+	fmt.Printf("Job Name:  %s\n", r.JobName)
+	fmt.Printf("Profile:   %s\n", r.Profile)
+	fmt.Printf("Source:    %s\n", r.Source)
+	fmt.Printf("Submitted: %s\n", r.Submitted.Local().Format(time.DateTime))
+	if !r.Completed.IsZero() {
+		fmt.Printf("Completed: %s (%s)\n", r.Completed.Local().Format(time.DateTime), elapsed(*r))
+	}
+	if r.RetCode != "" {
+		fmt.Printf("Return:    %s\n", r.RetCode)
+	}
+	if r.SpoolDigest != "" {
+		fmt.Printf("Spool:     %d bytes, sha256 %s\n", r.SpoolBytes, r.SpoolDigest)
+	}
+	if len(r.Steps) > 0 {
+		fmt.Println()
+		printSteps(r.Steps, nil)
+	}
+}
+
+func elapsed(r history.Record) string {
+	if r.Completed.IsZero() {
+		return ""
+	}
+	return r.Completed.Sub(r.Submitted).Round(time.Second).String()
+}
+
+// historyMu serializes access from concurrent workflow jobs: the database is
+// opened per operation so other zm processes are not locked out for long.
+var historyMu sync.Mutex
+
+// openHistory opens the history database in ~/.zm.
+func openHistory() (*history.DB, error) {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return history.Open(path)
+}
+
+// refreshHistory records the result of pending jobs of the current profile
+// that have completed since.
+func refreshHistory(db *history.DB) error {
+	pending, err := db.List(history.Query{Profile: cfg.DefaultProfile, Pending: true})
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	_, conn, err := openConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, r := range pending {
+		status, err := conn.GetJobStatus(r.JobID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", r.JobID, err)
+			continue
+		}
+		if status.Status == "OUTPUT" {
+			updateHistory(db, r.JobID, completion(conn, status))
+		}
+	}
+	return nil
+}
+
+// recordSubmit adds a submitted job and the JCL JES got to the history.
+// Failing to record never fails the submission, so errors are only reported
+// as warnings.
+func recordSubmit(source, jobid string, src []byte) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	db, err := openHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: job not recorded in history: %v\n", err)
+		return
+	}
+	defer db.Close()
+
+	rec := &history.Record{
+		JobID:     jobid,
+		JobName:   jcl.JobName(src),
+		Source:    source,
+		Profile:   cfg.DefaultProfile,
+		Submitted: time.Now(),
+		JCL:       string(src),
+	}
+	if err := db.Add(rec); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: job not recorded in history: %v\n", err)
+	}
+}
+
+// recordCompletion stores the result, step return codes and a digest of the
+// spool of a completed job.
+func recordCompletion(conn connection.Connection, status *connection.JobStatus) {
+	update := completion(conn, status)
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	db, err := openHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: completion not recorded in history: %v\n", err)
+		return
+	}
+	defer db.Close()
+	updateHistory(db, status.JobID, update)
+}
+
+func updateHistory(db *history.DB, jobid string, update func(*history.Record)) {
+	err := db.Update(cfg.DefaultProfile, jobid, update)
+	if err != nil && !errors.Is(err, history.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "Warning: completion not recorded in history: %v\n", err)
+	}
+}
+
+// completion fetches the step return codes and spool of a completed job and
+// returns the update to apply to its history record.
+func completion(conn connection.Connection, status *connection.JobStatus) func(*history.Record) {
+	var steps []spool.Step
+	if files, err := conn.ListSpoolFiles(status.JobID); err == nil {
+		steps, _ = jobSteps(conn, status.JobID, files)
+	}
+
+	var digest string
+	var size int
+	if output, err := conn.GetJobOutput(status.JobID); err == nil {
+		sum := sha256.Sum256(output)
+		digest = hex.EncodeToString(sum[:])
+		size = len(output)
+	}
+
+	completed := status.Ended
+	if completed.IsZero() {
+		completed = time.Now()
+	}
+
+	return func(r *history.Record) {
+		if status.JobName != "" {
+			r.JobName = status.JobName
+		}
+		r.Completed = completed
+		r.RetCode = status.RetCode
+		r.Steps = steps
+		r.SpoolDigest = digest
+		r.SpoolBytes = size
+	}
+}
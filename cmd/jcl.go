@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"zm/internal/connection"
+	"zm/internal/jcl"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintScan    bool
+	lintTimeout time.Duration
+)
+
+var jclCmd = &cobra.Command{
+	Use:   "jcl",
+	Short: "Work with JCL",
+}
+
+var jclLintCmd = &cobra.Command{
+	Use:   "lint <local-file> | <dataset(member)> | <uss-path> | -",
+	Short: "Check JCL syntax",
+	Long: `Check JCL syntax locally: statement fields, continuations, column 72,
+quotes and parentheses, keywords, symbolic parameters and in-stream data.
+
+With --scan the JCL is also submitted with TYPRUN=SCAN and the errors
+reported by JES are merged into the result.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJCLLint,
+}
+
+func init() {
+	rootCmd.AddCommand(jclCmd)
+	jclCmd.AddCommand(jclLintCmd)
+	jclLintCmd.Flags().BoolVar(&lintScan, "scan", false, "also submit with TYPRUN=SCAN and merge JES errors")
+	jclLintCmd.Flags().DurationVar(&lintTimeout, "timeout", 5*time.Minute, "maximum time to wait for the scan job")
+}
+
+func runJCLLint(cmd *cobra.Command, args []string) error {
+	source := args[0]
+
+	var conn connection.Connection
+	if !isLocalSource(source) || lintScan {
+		var err error
+		_, conn, err = openConnection()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+	}
+
+	src, err := loadJCL(conn, source)
+	if err != nil {
+		return err
+	}
+
+	diags := jcl.Lint(src)
+
+	if lintScan {
+		scanned, err := scanJCL(cmd.Context(), conn, src)
+		if err != nil {
+			return err
+		}
+		diags = append(diags, scanned...)
+	}
+
+	for _, d := range diags {
+		fmt.Printf("%s:%d: %s: %s\n", source, d.Line, d.Severity, d.Message)
+	}
+
+	if jcl.HasErrors(diags) {
+		return fmt.Errorf("%s has JCL errors", source)
+	}
+	if len(diags) == 0 {
+		fmt.Printf("%s: no problems found\n", source)
+	}
+	return nil
+}
+
+// scanJCL submits src with TYPRUN=SCAN and returns the errors JES reported.
+func scanJCL(ctx context.Context, conn connection.Connection, src []byte) ([]jcl.Diagnostic, error) {
+	scan, err := jcl.SetJobParameter(src, "TYPRUN", "SCAN")
+	if err != nil {
+		return nil, err
+	}
+
+	jobid, err := conn.SubmitJCL(scan)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Scan job %s submitted\n", jobid)
+
+	ctx, cancel := context.WithTimeout(ctx, lintTimeout)
+	defer cancel()
+	if _, err := connection.WaitForJob(ctx, conn, jobid, nil); err != nil {
+		return nil, err
+	}
+
+	output, err := conn.GetJobOutput(jobid)
+	if err != nil {
+		return nil, err
+	}
+	return jcl.ParseScanMessages(output, src), nil
+}
@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"text/tabwriter"
+	"time"
 
 	"zm/internal/connection"
+	"zm/internal/explain"
+	"zm/internal/spool"
 
 	"github.com/spf13/cobra"
 )
@@ -13,6 +17,12 @@ import (
 var (
 	jobsOwner  string
 	jobsOutput bool
+	jobsFilter connection.JobFilter
+	jobsSince  string
+	jobsSave   string
+	jobsSteps  bool
+	jobsWatch  bool
+	jobsEvery  time.Duration
 )
 
 var jobsCmd = &cobra.Command{
@@ -26,6 +36,49 @@ func init() {
 	rootCmd.AddCommand(jobsCmd)
 	jobsCmd.Flags().StringVar(&jobsOwner, "owner", "", "filter by owner (default: current user, use '*' for all)")
 	jobsCmd.Flags().BoolVarP(&jobsOutput, "output", "o", false, "show job output (requires jobid)")
+	jobsCmd.Flags().StringVar(&jobsSave, "save", "", "save each spool file and a job.json to a directory (requires jobid)")
+	jobsCmd.Flags().BoolVar(&jobsSteps, "steps", false, "show step return codes (requires jobid)")
+	jobsCmd.Flags().BoolVar(&jobsWatch, "watch", false, "refresh the job list until interrupted")
+	jobsCmd.Flags().DurationVar(&jobsEvery, "interval", 5*time.Second, "refresh interval for --watch")
+	addJobFilterFlags(jobsCmd)
+}
+
+// addJobFilterFlags registers the job list filters on cmd.
+func addJobFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&jobsFilter.Prefix, "prefix", "", "filter by job name prefix")
+	cmd.Flags().StringVar(&jobsFilter.Status, "status", "", "filter by status (ACTIVE, INPUT, OUTPUT)")
+	cmd.Flags().StringVar(&jobsFilter.RetCode, "rc", "", "filter by return code: failed, abend or a comparison like '>4'")
+	cmd.Flags().StringVar(&jobsFilter.Class, "class", "", "filter by job class")
+	cmd.Flags().StringVar(&jobsSince, "since", "", "only jobs submitted since a date (YYYY-MM-DD) or duration ago (e.g. 24h); z/OSMF only")
+	cmd.Flags().IntVar(&jobsFilter.MaxJobs, "max", 0, "maximum number of jobs to list")
+}
+
+// currentJobFilter builds the filter from the command line flags.
+func currentJobFilter() (connection.JobFilter, error) {
+	filter := jobsFilter
+	filter.Owner = jobsOwner
+	if jobsSince != "" {
+		since, err := parseSince(jobsSince, time.Now())
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = since
+	}
+	return filter, filter.Validate()
+}
+
+// parseSince accepts a date, an RFC 3339 timestamp or a duration before now.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (use YYYY-MM-DD or a duration like 24h)", s)
 }
 
 func runJobs(cmd *cobra.Command, args []string) error {
@@ -35,8 +88,16 @@ func runJobs(cmd *cobra.Command, args []string) error {
 	}
 	defer conn.Close()
 
+	cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+
 	if len(args) > 0 {
 		jobid := args[0]
+		if jobsWatch {
+			return fmt.Errorf("--watch lists jobs, use 'zm jobs watch %s' to wait for a job", jobid)
+		}
 
 		if jobsOutput {
 			output, err := conn.GetJobOutput(jobid)
@@ -47,19 +108,46 @@ func runJobs(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 
+		if jobsSave != "" {
+			return saveJobOutput(conn, jobid, jobsSave)
+		}
+
 		job, err := conn.GetJobStatus(jobid)
 		if err != nil {
 			return err
 		}
-		printJobDetail(job)
+		printJobDetail(job, cat)
+
+		if jobsSteps {
+			files, err := conn.ListSpoolFiles(jobid)
+			if err != nil {
+				return err
+			}
+			steps, err := jobSteps(conn, jobid, files)
+			if err != nil {
+				return err
+			}
+			fmt.Println()
+			printSteps(steps, cat)
+			explainFailure(conn, cat, jobid, job.RetCode)
+		}
 		return nil
 	}
 
-	if jobsOutput {
-		return fmt.Errorf("--output requires a jobid")
+	if jobsOutput || jobsSave != "" || jobsSteps {
+		return fmt.Errorf("--output, --save and --steps require a jobid")
+	}
+
+	filter, err := currentJobFilter()
+	if err != nil {
+		return err
+	}
+
+	if jobsWatch {
+		return watchJobList(cmd.Context(), conn, filter, cat)
 	}
 
-	jobs, err := conn.ListJobs(jobsOwner)
+	jobs, err := conn.ListJobs(filter)
 	if err != nil {
 		return err
 	}
@@ -69,28 +157,53 @@ func runJobs(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	printJobList(jobs)
+	printJobList(os.Stdout, jobs, cat)
 	return nil
 }
 
-func printJobList(jobs []connection.JobStatus) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+func printJobList(out io.Writer, jobs []connection.JobStatus, cat *explain.Catalog) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "JOBNAME\tJOBID\tOWNER\tSTATUS\tRC")
 	for _, j := range jobs {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", j.JobName, j.JobID, j.Owner, j.Status, j.RetCode)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", j.JobName, j.JobID, j.Owner, j.Status, explainRetCode(cat, j.RetCode))
 	}
 	w.Flush()
 }
 
-func printJobDetail(job *connection.JobStatus) {
+func printJobDetail(job *connection.JobStatus, cat *explain.Catalog) {
 	fmt.Printf("Job ID:    %s\n", job.JobID)
 	fmt.Printf("Job Name:  %s\n", job.JobName)
 	fmt.Printf("Owner:     %s\n", job.Owner)
 	fmt.Printf("Status:    %s\n", job.Status)
 	if job.RetCode != "" {
 		fmt.Printf("Return:    %s\n", job.RetCode)
+		if e, ok := cat.Lookup(job.RetCode); ok {
+			fmt.Printf("           %s: %s\n", e.Summary, e.Cause)
+		}
 	}
 	if job.Class != "" {
 		fmt.Printf("Class:     %s\n", job.Class)
 	}
+	if !job.Submitted.IsZero() {
+		fmt.Printf("Submitted: %s\n", job.Submitted.Local().Format(time.DateTime))
+	}
+	if !job.Started.IsZero() {
+		fmt.Printf("Started:   %s\n", job.Started.Local().Format(time.DateTime))
+	}
+	if !job.Ended.IsZero() {
+		fmt.Printf("Ended:     %s\n", job.Ended.Local().Format(time.DateTime))
+	}
+}
+
+func printSteps(steps []spool.Step, cat *explain.Catalog) {
+	if len(steps) == 0 {
+		fmt.Println("No step information available")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tPROCSTEP\tRC")
+	for _, s := range steps {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, s.ProcStep, explainRetCode(cat, s.RetCode))
+	}
+	w.Flush()
 }
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"zm/internal/connection"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 6, 14, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "24h", want: now.Add(-24 * time.Hour)},
+		{in: "90m", want: now.Add(-90 * time.Minute)},
+		{in: "2025-06-13", want: time.Date(2025, 6, 13, 0, 0, 0, 0, time.Local)},
+		{in: "2025-06-13T08:00:00Z", want: time.Date(2025, 6, 13, 8, 0, 0, 0, time.UTC)},
+		{in: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseSince(tt.in, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSince(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSince(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpoolFileNames(t *testing.T) {
+	files := []connection.SpoolFile{
+		{ID: 2, StepName: "JES2", DDName: "JESMSGLG"},
+		{ID: 102, StepName: "COMPILE", ProcStep: "COBOL", DDName: "SYSPRINT"},
+		{ID: 103, StepName: "COMPILE", ProcStep: "LKED", DDName: "SYSPRINT"},
+		{ID: 104, StepName: "RUN", DDName: "SYSOUT"},
+		{ID: 105, StepName: "RUN", DDName: "SYSOUT"},
+	}
+
+	want := []string{
+		"JES2.JESMSGLG.txt",
+		"COMPILE.COBOL.SYSPRINT.txt",
+		"COMPILE.LKED.SYSPRINT.txt",
+		"RUN.SYSOUT.txt",
+		"RUN.SYSOUT.105.txt",
+	}
+
+	got := spoolFileNames(files)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("name %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
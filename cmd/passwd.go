@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+
+	"zm/internal/config"
+	"zm/internal/connection"
+
+	"github.com/spf13/cobra"
+)
+
+var passwdCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Change your z/OS password",
+	Long: `Change the password of the profile's user on z/OS and store the new one
+in the profile. This also works when the password has already expired.`,
+	Args: cobra.NoArgs,
+	RunE: runPasswd,
+}
+
+func init() {
+	rootCmd.AddCommand(passwdCmd)
+}
+
+func runPasswd(cmd *cobra.Command, args []string) error {
+	profile, err := GetCurrentProfile()
+	if err != nil {
+		return err
+	}
+	if profile.Password == "" {
+		return fmt.Errorf("profile '%s' logs in with a client certificate and has no password", cfg.DefaultProfile)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	newPassword := promptPassword(reader, "New password")
+	if newPassword == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+	if promptPassword(reader, "Repeat new password") != newPassword {
+		return fmt.Errorf("passwords do not match")
+	}
+	if newPassword == profile.Password {
+		return fmt.Errorf("the new password must differ from the current one")
+	}
+
+	opts, err := connectionOptions(profile)
+	if err != nil {
+		return err
+	}
+	conn, err := connection.NewConnection(profile.Host, profile.Port, profile.User, profile.Password, profile.Protocol, opts...)
+	if err != nil {
+		return err
+	}
+	// An expired password is what this command is for
+	if err := conn.Connect(); err != nil && !errors.Is(err, connection.ErrPasswordExpired) {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.ChangePassword(newPassword); err != nil {
+		return err
+	}
+
+	if err := savePassword(cfgFile, cfg.DefaultProfile, newPassword); err != nil {
+		return fmt.Errorf("password changed on %s but not saved in the config, update it by hand: %w", profile.Host, err)
+	}
+
+	fmt.Printf("Password of %s changed and saved in profile '%s'\n", profile.User, cfg.DefaultProfile)
+	return nil
+}
+
+// savePassword stores password in a profile of the config file. The file is
+// read again since cfg carries command line overrides such as --profile.
+func savePassword(path, name, password string) error {
+	saved, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	p, ok := saved.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	p.Password = password
+	return saved.Save(path)
+}
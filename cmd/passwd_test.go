@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"zm/internal/config"
+)
+
+func TestSavePassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zmconfig")
+	data := []byte(`profiles:
+  dev:
+    host: dev.example.com
+    user: IBMUSER
+    password: old
+  prod:
+    host: prod.example.com
+    user: IBMUSER
+    password: old
+default_profile: dev
+`)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := savePassword(path, "prod", "new"); err != nil {
+		t.Fatalf("savePassword error: %v", err)
+	}
+	saved, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := saved.Profiles["prod"].Password; got != "new" {
+		t.Errorf("prod password = %q, want new", got)
+	}
+	if got := saved.Profiles["dev"].Password; got != "old" {
+		t.Errorf("dev password = %q, want old", got)
+	}
+	if saved.DefaultProfile != "dev" {
+		t.Errorf("default profile = %q, want dev", saved.DefaultProfile)
+	}
+
+	if err := savePassword(path, "test", "new"); err == nil {
+		t.Error("expected error for an unknown profile")
+	}
+}
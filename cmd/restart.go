@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"zm/internal/connection"
+	"zm/internal/jcl"
+	"zm/internal/spool"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	restartFrom    string
+	restartComment bool
+	restartEdit    bool
+	restartYes     bool
+	restartWait    bool
+)
+
+var jobsRestartCmd = &cobra.Command{
+	Use:   "restart <jobid>",
+	Short: "Submit a job again starting from a step",
+	Long: `Fetch the JCL of a job, rewrite it to start at a step and submit it.
+
+By default RESTART=STEP (or STEP.PROCSTEP) is set on the JOB statement.
+With --comment the steps before it are turned into comments instead, which
+also works where RESTART= is not allowed, but references to those steps in
+COND or IF are reported and have to be fixed by hand.
+
+Without --from the first failed step of the job is suggested.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobsRestart,
+}
+
+func init() {
+	jobsCmd.AddCommand(jobsRestartCmd)
+	jobsRestartCmd.Flags().StringVar(&restartFrom, "from", "", "step to restart from (STEP or STEP.PROCSTEP)")
+	jobsRestartCmd.Flags().BoolVar(&restartComment, "comment", false, "comment out the preceding steps instead of setting RESTART=")
+	jobsRestartCmd.Flags().BoolVarP(&restartEdit, "edit", "e", false, "edit the rewritten JCL before submitting")
+	jobsRestartCmd.Flags().BoolVarP(&restartYes, "yes", "y", false, "use the suggested step without asking")
+	jobsRestartCmd.Flags().BoolVarP(&restartWait, "wait", "w", false, "wait for the new job to complete")
+}
+
+func runJobsRestart(cmd *cobra.Command, args []string) error {
+	jobid := strings.ToUpper(args[0])
+
+	_, conn, err := openConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	src, source, err := originalJCL(conn, jobid, false)
+	if err != nil {
+		return err
+	}
+
+	from := strings.ToUpper(restartFrom)
+	if from == "" {
+		if from, err = chooseRestartStep(conn, jobid); err != nil {
+			return err
+		}
+	}
+
+	step, procStep, _ := strings.Cut(from, ".")
+	if !slices.Contains(jcl.StepNames(src), step) {
+		return fmt.Errorf("step %s not found in the JCL of %s", step, jobid)
+	}
+
+	var rewritten []byte
+	if restartComment {
+		if procStep != "" {
+			fmt.Fprintf(os.Stderr, "Steps inside a procedure cannot be commented out, restarting at job step %s\n", step)
+		}
+		var warnings []string
+		rewritten, warnings, err = jcl.CommentSteps(src, step)
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+	} else {
+		rewritten, err = jcl.SetJobParameter(src, "RESTART", from)
+	}
+	if err != nil {
+		return err
+	}
+
+	if restartEdit {
+		if rewritten, err = editJCL(jobid, rewritten); err != nil {
+			return err
+		}
+	}
+
+	newid, err := conn.SubmitJCL(rewritten)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Job %s submitted (restart of %s from %s)\n", newid, jobid, from)
+	recordSubmit(source, newid, rewritten)
+
+	if !restartWait {
+		return nil
+	}
+	return awaitJob(cmd.Context(), conn, newid, false)
+}
+
+// chooseRestartStep suggests the first failed step of the job and lets the
+// user confirm or change it.
+func chooseRestartStep(conn connection.Connection, jobid string) (string, error) {
+	files, err := conn.ListSpoolFiles(jobid)
+	if err != nil {
+		return "", fmt.Errorf("cannot read the steps of %s, use --from: %w", jobid, err)
+	}
+	steps, err := jobSteps(conn, jobid, files)
+	if err != nil {
+		return "", err
+	}
+
+	suggested := failedStep(steps)
+	if suggested == "" {
+		return "", fmt.Errorf("no failed step found in %s, use --from", jobid)
+	}
+	if restartYes {
+		return suggested, nil
+	}
+
+	printSteps(steps, nil)
+	fmt.Println()
+	reader := bufio.NewReader(os.Stdin)
+	return strings.ToUpper(prompt(reader, "Restart from step", suggested)), nil
+}
+
+// failedStep returns the restart point of the first step that abended or
+// ended with CC above 4, as STEP or STEP.PROCSTEP. Flushed steps are skipped.
+func failedStep(steps []spool.Step) string {
+	for _, s := range steps {
+		if s.RetCode == "FLUSH" || !connection.MatchRetCode(s.RetCode, "failed") {
+			continue
+		}
+		if s.ProcStep != "" {
+			return s.Name + "." + s.ProcStep
+		}
+		return s.Name
+	}
+	return ""
+}
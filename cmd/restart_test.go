@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+
+	"zm/internal/spool"
+)
+
+func TestFailedStep(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []spool.Step
+		want  string
+	}{
+		{
+			name: "abend",
+			steps: []spool.Step{
+				{Name: "STEP1", RetCode: "CC 0000"},
+				{Name: "STEP2", RetCode: "ABEND S0C7"},
+				{Name: "STEP3", RetCode: "FLUSH"},
+			},
+			want: "STEP2",
+		},
+		{
+			name: "procedure step",
+			steps: []spool.Step{
+				{Name: "COMPILE", ProcStep: "COBOL", RetCode: "CC 0004"},
+				{Name: "COMPILE", ProcStep: "LKED", RetCode: "CC 0008"},
+			},
+			want: "COMPILE.LKED",
+		},
+		{
+			name: "flushed steps are not restart points",
+			steps: []spool.Step{
+				{Name: "STEP1", RetCode: "FLUSH"},
+				{Name: "STEP2", RetCode: "CC 0012"},
+			},
+			want: "STEP2",
+		},
+		{
+			name:  "nothing failed",
+			steps: []spool.Step{{Name: "STEP1", RetCode: "CC 0004"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failedStep(tt.steps); got != tt.want {
+				t.Errorf("failedStep() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
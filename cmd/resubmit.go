@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"zm/internal/connection"
+	"zm/internal/editor"
+	"zm/internal/history"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	resubmitEdit    bool
+	resubmitHistory bool
+	resubmitWait    bool
+)
+
+var jobsResubmitCmd = &cobra.Command{
+	Use:   "resubmit <jobid>",
+	Short: "Submit the JCL of a previous job again",
+	Long: `Fetch the JCL a job was submitted with and submit it again, optionally
+after editing it in $EDITOR.
+
+The JCL is taken from the job's JESJCLIN spool (z/OSMF only) and, when that
+is not available, from the local history of jobs submitted with zm.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobsResubmit,
+}
+
+func init() {
+	jobsCmd.AddCommand(jobsResubmitCmd)
+	jobsResubmitCmd.Flags().BoolVarP(&resubmitEdit, "edit", "e", false, "edit the JCL before submitting")
+	jobsResubmitCmd.Flags().BoolVar(&resubmitHistory, "history", false, "take the JCL from the local history only")
+	jobsResubmitCmd.Flags().BoolVarP(&resubmitWait, "wait", "w", false, "wait for the new job to complete")
+}
+
+func runJobsResubmit(cmd *cobra.Command, args []string) error {
+	jobid := strings.ToUpper(args[0])
+
+	_, conn, err := openConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	src, source, err := originalJCL(conn, jobid, resubmitHistory)
+	if err != nil {
+		return err
+	}
+
+	if resubmitEdit {
+		if src, err = editJCL(jobid, src); err != nil {
+			return err
+		}
+	}
+
+	newid, err := conn.SubmitJCL(src)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Job %s submitted (resubmit of %s)\n", newid, jobid)
+	recordSubmit(source, newid, src)
+
+	if !resubmitWait {
+		return nil
+	}
+	return awaitJob(cmd.Context(), conn, newid, false)
+}
+
+// originalJCL returns the JCL a job was submitted with and the source to
+// record for the new submission: the original source when the job is in the
+// history, the job ID otherwise.
+func originalJCL(conn connection.Connection, jobid string, historyOnly bool) ([]byte, string, error) {
+	var rec *history.Record
+	if db, err := openHistory(); err == nil {
+		rec, _ = db.Get(cfg.DefaultProfile, jobid)
+		db.Close()
+	}
+	source := jobid
+	if rec != nil {
+		source = rec.Source
+	}
+
+	var jesErr error
+	if !historyOnly {
+		src, err := conn.GetJobJCL(jobid)
+		if err == nil && len(bytes.TrimSpace(src)) > 0 {
+			return src, source, nil
+		}
+		jesErr = err
+	}
+
+	if rec != nil && rec.JCL != "" {
+		if jesErr != nil {
+			fmt.Fprintf(os.Stderr, "Using JCL from local history (%v)\n", jesErr)
+		}
+		return []byte(rec.JCL), source, nil
+	}
+
+	if jesErr != nil {
+		return nil, "", fmt.Errorf("%w, and %s has no JCL in the local history", jesErr, jobid)
+	}
+	return nil, "", fmt.Errorf("no JCL found for %s", jobid)
+}
+
+// editJCL opens src in the user's editor and returns the edited JCL.
+func editJCL(name string, src []byte) ([]byte, error) {
+	tmpFile, err := writeTempFile(name+".jcl", src)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpFile)
+
+	if err := editor.Open(tmpFile); err != nil {
+		return nil, err
+	}
+
+	edited, err := os.ReadFile(tmpFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edited file: %w", err)
+	}
+	if len(bytes.TrimSpace(edited)) == 0 {
+		return nil, fmt.Errorf("JCL is empty, nothing submitted")
+	}
+	return edited, nil
+}
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"zm/internal/config"
 	"zm/internal/connection"
@@ -40,7 +44,14 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels the command context so long-running waits can stop cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		if errors.Is(err, connection.ErrPasswordExpired) {
+			fmt.Fprintln(os.Stderr, "Change the expired password with 'zm passwd'.")
+		}
 		os.Exit(1)
 	}
 }
@@ -63,7 +74,11 @@ func openConnection() (*config.Profile, connection.Connection, error) {
 		return nil, nil, err
 	}
 
-	conn, err := connection.NewConnection(profile.Host, profile.Port, profile.User, profile.Password, profile.Protocol)
+	opts, err := connectionOptions(profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := connection.NewConnection(profile.Host, profile.Port, profile.User, profile.Password, profile.Protocol, opts...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -72,3 +87,54 @@ func openConnection() (*config.Profile, connection.Connection, error) {
 	}
 	return profile, conn, nil
 }
+
+// connectionOptions translates the transport settings of a profile.
+func connectionOptions(profile *config.Profile) ([]connection.Option, error) {
+	var opts []connection.Option
+	if t := profile.TLS; t != nil {
+		mode := t.Mode
+		if mode == "none" {
+			mode = connection.TLSNone
+		}
+		caFile, err := config.ExpandHome(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		certFile, err := config.ExpandHome(t.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		keyFile, err := config.ExpandHome(t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if t.Insecure {
+			fmt.Fprintf(os.Stderr, "Warning: TLS certificate verification is disabled for %s\n", profile.Host)
+		}
+		opts = append(opts, connection.WithTLS(connection.TLSOptions{
+			Mode:         mode,
+			CAFile:       caFile,
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			CertPassword: t.CertPassword,
+			ServerName:   t.ServerName,
+			Fingerprint:  t.Fingerprint,
+			Insecure:     t.Insecure,
+		}))
+	}
+	if f := profile.FTP; f != nil {
+		opts = append(opts, connection.WithDataChannel(connection.DataChannel{
+			Active:         f.Active,
+			IgnorePASVHost: f.IgnorePASVHost,
+			Compress:       f.Compress,
+		}))
+		opts = append(opts, connection.WithPoolSize(f.PoolSize))
+	}
+	if profile.Protocol == "zosmf" {
+		// Without a home directory every command logs in anew
+		if path, err := connection.DefaultTokenPath(cfg.DefaultProfile); err == nil {
+			opts = append(opts, connection.WithTokenCache(path))
+		}
+	}
+	return opts, nil
+}
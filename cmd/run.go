@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"zm/internal/connection"
+	"zm/internal/workflow"
+
+	"github.com/spf13/cobra"
+)
+
+var runParallel int
+
+var runCmd = &cobra.Command{
+	Use:   "run <workflow.yaml>",
+	Short: "Run a workflow of dependent jobs",
+	Long: `Submit the jobs of a workflow file in dependency order, running independent
+jobs in parallel, and print a report when all of them are done.
+
+Each job names its JCL (DS(MEMBER), local file or USS path), the jobs it
+needs and a condition on their outcome:
+
+  success   every needed job ended with CC <= max_rc (default 4)
+  failure   at least one needed job failed
+  always    run once the needed jobs are done
+  <expr>    every needed job ran and matches a return code filter
+            such as "<=8" or "abend" (see zm jobs --rc)
+
+Jobs whose condition is not met are skipped, and so are the jobs that need
+them with the default condition. Workflow and job vars are substituted as
+${NAME} and &NAME placeholders, as in zm submit.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWorkflow,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().IntVar(&runParallel, "parallel", 0, "maximum jobs running at once (default: max_parallel from the workflow)")
+}
+
+func runWorkflow(cmd *cobra.Command, args []string) error {
+	wf, err := workflow.Load(args[0])
+	if err != nil {
+		return err
+	}
+	if runParallel < 0 {
+		return fmt.Errorf("invalid --parallel %d", runParallel)
+	}
+
+	profile, conn, err := openConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	runner := &workflow.Runner{
+		Conn:        conn,
+		MaxParallel: runParallel,
+		Submit: func(job workflow.Job) (string, error) {
+			jobid, src, err := submitSource(profile, conn, job.JCL, wf.JobVars(job))
+			if err == nil {
+				recordSubmit(job.JCL, jobid, src)
+			}
+			return jobid, err
+		},
+		Completed: func(job workflow.Job, status *connection.JobStatus) {
+			recordCompletion(conn, status)
+		},
+		Logf: func(format string, args ...any) {
+			fmt.Printf("%s "+format+"\n", append([]any{time.Now().Format(time.TimeOnly)}, args...)...)
+		},
+	}
+
+	if wf.Name != "" {
+		fmt.Printf("Running %s\n", wf.Name)
+	}
+	results := runner.Run(cmd.Context(), wf)
+
+	fmt.Println()
+	printWorkflowReport(results)
+
+	failed := 0
+	for _, r := range results {
+		if r.State == workflow.StateFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs failed", failed, len(results))
+	}
+	return nil
+}
+
+func printWorkflowReport(results []workflow.Result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tJOBID\tRESULT\tRC\tDURATION\tREASON")
+	for _, r := range results {
+		duration := ""
+		if !r.End.IsZero() {
+			duration = r.End.Sub(r.Start).Round(time.Second).String()
+		}
+		reason := r.Reason
+		if r.State == workflow.StateFailed && reason == r.RetCode {
+			reason = ""
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Name, r.JobID, r.State, r.RetCode, duration, reason)
+	}
+	w.Flush()
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zm/internal/connection"
+	"zm/internal/spool"
+)
+
+// jobSteps reads JESYSMSG and returns the step results of a job.
+func jobSteps(conn connection.Connection, jobid string, files []connection.SpoolFile) ([]spool.Step, error) {
+	for _, f := range files {
+		if f.DDName == "JESYSMSG" {
+			data, err := conn.ReadSpoolFile(jobid, f.ID)
+			if err != nil {
+				return nil, err
+			}
+			return spool.ParseSteps(data), nil
+		}
+	}
+	return nil, nil
+}
+
+type jobManifest struct {
+	JobID     string          `json:"jobid"`
+	JobName   string          `json:"jobname"`
+	Owner     string          `json:"owner"`
+	Status    string          `json:"status"`
+	RetCode   string          `json:"retcode,omitempty"`
+	Class     string          `json:"class,omitempty"`
+	Submitted *time.Time      `json:"submitted,omitempty"`
+	Started   *time.Time      `json:"started,omitempty"`
+	Ended     *time.Time      `json:"ended,omitempty"`
+	Steps     []spool.Step    `json:"steps"`
+	Files     []manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	ID       int    `json:"id"`
+	DDName   string `json:"ddname"`
+	StepName string `json:"stepname"`
+	ProcStep string `json:"procstep,omitempty"`
+	File     string `json:"file"`
+}
+
+// saveJobOutput writes every spool file of a job to dir as STEP.DDNAME.txt,
+// plus a job.json with status, step return codes and timing.
+func saveJobOutput(conn connection.Connection, jobid, dir string) error {
+	job, err := conn.GetJobStatus(jobid)
+	if err != nil {
+		return err
+	}
+	files, err := conn.ListSpoolFiles(jobid)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	manifest := jobManifest{
+		JobID:     job.JobID,
+		JobName:   job.JobName,
+		Owner:     job.Owner,
+		Status:    job.Status,
+		RetCode:   job.RetCode,
+		Class:     job.Class,
+		Submitted: optionalTime(job.Submitted),
+		Started:   optionalTime(job.Started),
+		Ended:     optionalTime(job.Ended),
+		Steps:     []spool.Step{},
+	}
+
+	names := spoolFileNames(files)
+	for i, f := range files {
+		data, err := conn.ReadSpoolFile(jobid, f.ID)
+		if err != nil {
+			return fmt.Errorf("failed to read DD %s: %w", f.DDName, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, names[i]), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", names[i], err)
+		}
+		if f.DDName == "JESYSMSG" {
+			manifest.Steps = spool.ParseSteps(data)
+		}
+		manifest.Files = append(manifest.Files, manifestEntry{
+			ID:       f.ID,
+			DDName:   f.DDName,
+			StepName: f.StepName,
+			ProcStep: f.ProcStep,
+			File:     names[i],
+		})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "job.json"), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write job.json: %w", err)
+	}
+
+	fmt.Printf("Saved %d spool files of %s to %s\n", len(files), jobid, dir)
+	return nil
+}
+
+// spoolFileNames returns STEP.DDNAME.txt for each file, adding the procedure
+// step and then the file ID when names would otherwise collide.
+func spoolFileNames(files []connection.SpoolFile) []string {
+	base := func(f connection.SpoolFile, withProc bool) string {
+		parts := []string{f.StepName}
+		if withProc && f.ProcStep != "" {
+			parts = append(parts, f.ProcStep)
+		}
+		parts = append(parts, f.DDName)
+		for i, p := range parts {
+			if p == "" {
+				parts[i] = "NONE"
+			}
+		}
+		return strings.Join(parts, ".")
+	}
+
+	count := make(map[string]int)
+	for _, f := range files {
+		count[base(f, false)]++
+	}
+
+	names := make([]string, len(files))
+	used := make(map[string]bool)
+	for i, f := range files {
+		name := base(f, false)
+		if count[name] > 1 {
+			name = base(f, true)
+		}
+		if used[name] {
+			name = fmt.Sprintf("%s.%d", name, f.ID)
+		}
+		used[name] = true
+		names[i] = filepath.Base(name) + ".txt"
+	}
+	return names
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
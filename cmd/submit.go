@@ -1,106 +1,241 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
+	"zm/internal/config"
 	"zm/internal/connection"
+	"zm/internal/jcl"
 
 	"github.com/spf13/cobra"
 )
 
-var submitWait bool
+var (
+	submitWait    bool
+	submitTimeout time.Duration
+	submitVars    []string
+	submitVarFile string
+	submitNotify  bool
+)
 
 var submitCmd = &cobra.Command{
-	Use:   "submit <dataset(member)> | <local-file>",
+	Use:   "submit <dataset(member)> | <local-file> | <uss-path> | -",
 	Short: "Submit JCL for execution",
-	Long:  `Submit JCL from a PDS member or local file.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runSubmit,
+	Long: `Submit JCL from a PDS member, a local file, a USS file or stdin ("-").
+Local files take precedence over USS paths with the same name.
+
+Placeholders written as ${NAME} or &NAME are replaced before submission.
+Values come from --vars and --var (the latter wins); ${USER}, ${HLQ} and
+${SUFFIX} (a random character for unique job names) are always available.
+&NAME is only replaced for variables you pass, so JES symbols are kept.
+
+Over z/OSMF, PDS members are submitted directly from the dataset when no
+--var or --vars is given, so the JCL is not downloaded and placeholders,
+the builtins included, are left to JES.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSubmit,
 }
 
 func init() {
 	rootCmd.AddCommand(submitCmd)
 	submitCmd.Flags().BoolVarP(&submitWait, "wait", "w", false, "wait for job to complete")
+	submitCmd.Flags().DurationVar(&submitTimeout, "timeout", 0, "maximum time to wait for the job, e.g. 10m (default: no limit)")
+	submitCmd.Flags().StringArrayVar(&submitVars, "var", nil, "set a JCL variable (NAME=VALUE, repeatable)")
+	submitCmd.Flags().StringVar(&submitVarFile, "vars", "", "YAML file with JCL variables")
+	submitCmd.Flags().BoolVar(&submitNotify, "notify", false, "run the profile's notify hooks when the job completes (requires --wait)")
 }
 
 func runSubmit(cmd *cobra.Command, args []string) error {
-	profile, err := GetCurrentProfile()
+	if submitTimeout != 0 && !submitWait {
+		return fmt.Errorf("--timeout requires --wait")
+	}
+	if submitNotify && !submitWait {
+		return fmt.Errorf("--notify requires --wait")
+	}
+
+	profile, conn, err := openConnection()
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
-	conn, err := connection.NewConnection(profile.Host, profile.Port, profile.User, profile.Password, profile.Protocol)
+	if submitNotify && len(profile.Notify) == 0 {
+		return fmt.Errorf("no notify hooks configured for profile '%s'", cfg.DefaultProfile)
+	}
+
+	vars, err := submitTemplateVars()
 	if err != nil {
 		return err
 	}
-	if err := conn.Connect(); err != nil {
+	jobid, src, err := submitSource(profile, conn, args[0], vars)
+	if err != nil {
 		return err
 	}
-	defer conn.Close()
 
-	source := args[0]
-	var jcl []byte
+	fmt.Printf("Job %s submitted\n", jobid)
+	recordSubmit(args[0], jobid, src)
+
+	if !submitWait {
+		return nil
+	}
+
+	ctx := cmd.Context()
+	if submitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, submitTimeout)
+		defer cancel()
+	}
 
-	if _, err := os.Stat(source); err == nil {
-		// Local file
-		jcl, err = os.ReadFile(source)
+	return awaitJob(ctx, conn, jobid, submitNotify)
+}
+
+// awaitJob waits for a submitted job, records its result in the history,
+// runs the notify hooks if asked to and explains why the job failed, if it did.
+func awaitJob(ctx context.Context, conn connection.Connection, jobid string, notify bool) error {
+	status, err := waitForJob(ctx, conn, jobid)
+	if err != nil {
+		return err
+	}
+	recordCompletion(conn, status)
+	if notify {
+		notifyJob(ctx, status)
+	}
+
+	cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+	explainFailure(conn, cat, jobid, status.RetCode)
+
+	if strings.Contains(status.RetCode, "ABEND") {
+		return fmt.Errorf("job ended with %s", status.RetCode)
+	}
+	return nil
+}
+
+// submitSource submits the JCL named by source with its placeholders
+// replaced from vars and the builtins, and returns the job ID and the JCL
+// JES got. Over z/OSMF a member is submitted from the dataset when there
+// are no vars, and the JCL returned is nil since it was never read. FTP
+// has no such submit, so there the member is always read and templated.
+func submitSource(profile *config.Profile, conn connection.Connection, source string, vars map[string]string) (string, []byte, error) {
+	if profile.Protocol == "zosmf" && len(vars) == 0 && isMemberSource(source) {
+		dataset, member, err := parseDSN(source)
 		if err != nil {
-			return fmt.Errorf("failed to read %s: %w", source, err)
+			return "", nil, err
 		}
-	} else {
-		// PDS member
-		dataset, member, err := parseDSN(source)
+		jobid, err := conn.SubmitMember(dataset, member)
+		return jobid, nil, err
+	}
+
+	src, err := loadJCL(conn, source)
+	if err != nil {
+		return "", nil, err
+	}
+	out := applyTemplate(profile, source, src, vars)
+	jobid, err := conn.SubmitJCL(out)
+	return jobid, out, err
+}
+
+// loadJCL reads JCL from stdin ("-"), a local file, a USS file or a PDS member.
+// conn is only used for USS files and members and may be nil otherwise.
+func loadJCL(conn connection.Connection, source string) ([]byte, error) {
+	switch {
+	case source == "-":
+		jcl, err := io.ReadAll(os.Stdin)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
 		}
-		jcl, err = conn.ReadMember(dataset, member)
+		return jcl, nil
+
+	case isLocalFile(source):
+		jcl, err := os.ReadFile(source)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("failed to read %s: %w", source, err)
 		}
+		return jcl, nil
+
+	case strings.HasPrefix(source, "/"):
+		return conn.ReadFile(source)
 	}
 
-	jobid, err := conn.SubmitJCL(jcl)
+	dataset, member, err := parseDSN(source)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return conn.ReadMember(dataset, member)
+}
 
-	fmt.Printf("Job %s submitted\n", jobid)
-
-	if !submitWait {
-		return nil
-	}
+// isLocalSource reports whether source can be read without a connection.
+func isLocalSource(source string) bool {
+	return source == "-" || isLocalFile(source)
+}
 
-	return waitForJob(conn, jobid)
+func isLocalFile(source string) bool {
+	info, err := os.Stat(source)
+	return err == nil && !info.IsDir()
 }
 
-func waitForJob(conn connection.Connection, jobid string) error {
-	fmt.Printf("Waiting for %s...", jobid)
+// isMemberSource reports whether source names a PDS member.
+func isMemberSource(source string) bool {
+	return !isLocalSource(source) && !strings.HasPrefix(source, "/")
+}
 
-	for {
-		status, err := conn.GetJobStatus(jobid)
+// submitTemplateVars collects the variables of --vars and --var.
+func submitTemplateVars() (map[string]string, error) {
+	vars := make(map[string]string)
+	if submitVarFile != "" {
+		fileVars, err := jcl.LoadVars(submitVarFile)
 		if err != nil {
-			fmt.Println()
-			return err
+			return nil, err
 		}
-
-		if status.Status == "OUTPUT" {
-			fmt.Println()
-			rc := status.RetCode
-			if rc == "" {
-				rc = "N/A"
-			}
-			fmt.Printf("Job %s completed — %s\n", jobid, rc)
-
-			if strings.Contains(rc, "ABEND") {
-				return fmt.Errorf("job ended with %s", rc)
-			}
-			return nil
+		for name, value := range fileVars {
+			vars[name] = value
+		}
+	}
+	for _, v := range submitVars {
+		name, value, err := jcl.ParseVar(v)
+		if err != nil {
+			return nil, err
 		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// applyTemplate replaces the placeholders of the JCL read from source,
+// warning about undefined ones and records made too long.
+func applyTemplate(profile *config.Profile, source string, src []byte, vars map[string]string) []byte {
+	out, unresolved := jcl.Substitute(src, vars, jcl.Builtins(profile.User, profile.HLQ))
+	for _, name := range unresolved {
+		fmt.Fprintf(os.Stderr, "Warning: ${%s} is not defined in %s, left as is\n", name, source)
+	}
+	for _, line := range jcl.LongLines(src, out) {
+		fmt.Fprintf(os.Stderr, "Warning: line %d of %s extends past column 71 after substitution\n", line, source)
+	}
+	return out
+}
+
+func waitForJob(ctx context.Context, conn connection.Connection, jobid string) (*connection.JobStatus, error) {
+	fmt.Printf("Waiting for %s...", jobid)
 
+	status, err := connection.WaitForJob(ctx, conn, jobid, func(*connection.JobStatus) {
 		fmt.Print(".")
-		time.Sleep(2 * time.Second)
+	})
+	fmt.Println()
+	if err != nil {
+		return nil, err
+	}
+
+	rc := status.RetCode
+	if rc == "" {
+		rc = "N/A"
 	}
+	fmt.Printf("Job %s completed — %s\n", jobid, rc)
+	return status, nil
 }
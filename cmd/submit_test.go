@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"testing"
+
+	"zm/internal/config"
+	"zm/internal/connection"
+)
+
+// submitStub serves members from memory and records how jobs were submitted.
+type submitStub struct {
+	connection.Connection
+	members map[string]string
+
+	reads  int    // calls of ReadMember
+	jcl    string // JCL passed to SubmitJCL
+	member string // member passed to SubmitMember
+}
+
+func (s *submitStub) ReadMember(dataset, member string) ([]byte, error) {
+	s.reads++
+	return []byte(s.members[dataset+"("+member+")"]), nil
+}
+
+func (s *submitStub) SubmitJCL(jcl []byte) (string, error) {
+	s.jcl = string(jcl)
+	return "JOB00001", nil
+}
+
+func (s *submitStub) SubmitMember(dataset, member string) (string, error) {
+	s.member = dataset + "(" + member + ")"
+	return "JOB00001", nil
+}
+
+func TestSubmitSource(t *testing.T) {
+	members := map[string]string{
+		"IBMUSER.JCL(BUILD)": "//${USER}A JOB\n//S1 EXEC PGM=IEFBR14\n",
+		"IBMUSER.JCL(PLAIN)": "//PLAIN JOB\n//S1 EXEC PGM=IEFBR14\n",
+	}
+	tests := []struct {
+		name       string
+		protocol   string
+		source     string
+		vars       map[string]string
+		wantJCL    string
+		wantMember string
+	}{
+		{"member over zosmf", "zosmf", "IBMUSER.JCL(BUILD)", nil, "", "IBMUSER.JCL(BUILD)"},
+		{"builtin in member with vars", "zosmf", "IBMUSER.JCL(BUILD)", map[string]string{"X": "1"}, "//IBMUSERA JOB\n//S1 EXEC PGM=IEFBR14\n", ""},
+		{"builtin in member over ftp", "ftp", "IBMUSER.JCL(BUILD)", nil, "//IBMUSERA JOB\n//S1 EXEC PGM=IEFBR14\n", ""},
+		{"member over ftp", "ftp", "IBMUSER.JCL(PLAIN)", nil, "//PLAIN JOB\n//S1 EXEC PGM=IEFBR14\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &submitStub{members: members}
+			profile := &config.Profile{Protocol: tt.protocol, User: "ibmuser"}
+
+			jobid, src, err := submitSource(profile, conn, tt.source, tt.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if jobid != "JOB00001" {
+				t.Errorf("jobid = %q", jobid)
+			}
+			if conn.jcl != tt.wantJCL || conn.member != tt.wantMember {
+				t.Errorf("submitted JCL %q, member %q; want %q, %q", conn.jcl, conn.member, tt.wantJCL, tt.wantMember)
+			}
+			if string(src) != tt.wantJCL {
+				t.Errorf("returned JCL %q, want %q", src, tt.wantJCL)
+			}
+			if tt.wantMember != "" && conn.reads != 0 {
+				t.Errorf("member read %d times before a dataset submit", conn.reads)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"zm/internal/connection"
+	"zm/internal/explain"
+	"zm/internal/notify"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchTimeout  time.Duration
+	watchNoNotify bool
+)
+
+var jobsWatchCmd = &cobra.Command{
+	Use:   "watch [jobid...]",
+	Short: "Wait for jobs to finish and run the notify hooks",
+	Long: `Wait until the given jobs, or the jobs matching the list filters that have
+not finished yet, reach OUTPUT. Each completion is printed and the notify
+hooks of the profile are run.
+
+Hooks are configured per profile in ~/.zmconfig:
+
+  notify:
+    - command: 'echo "$ZM_JOBNAME $ZM_JOBID ended with $ZM_RETCODE"'
+    - desktop: true
+      on: failure
+    - webhook: http://localhost:9000/hooks/zm
+
+Commands get the job in ZM_JOBID, ZM_JOBNAME, ZM_OWNER, ZM_STATUS,
+ZM_RETCODE, ZM_FAILED and ZM_PROFILE, and as JSON on stdin. Webhooks receive
+the same JSON as a POST. "on" is always (default), success or failure.`,
+	RunE: runJobsWatch,
+}
+
+func init() {
+	jobsCmd.AddCommand(jobsWatchCmd)
+	jobsWatchCmd.Flags().StringVar(&jobsOwner, "owner", "", "filter by owner (default: current user, use '*' for all)")
+	jobsWatchCmd.Flags().DurationVar(&watchTimeout, "timeout", 0, "stop watching after this long, e.g. 2h (default: no limit)")
+	jobsWatchCmd.Flags().BoolVar(&watchNoNotify, "no-notify", false, "only print completions, don't run the hooks")
+	addJobFilterFlags(jobsWatchCmd)
+}
+
+func runJobsWatch(cmd *cobra.Command, args []string) error {
+	profile, conn, err := openConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if !watchNoNotify && len(profile.Notify) == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: no notify hooks configured for profile '%s'\n", cfg.DefaultProfile)
+	}
+
+	jobids := make([]string, len(args))
+	for i, a := range args {
+		jobids[i] = strings.ToUpper(a)
+	}
+	if len(jobids) == 0 {
+		filter, err := currentJobFilter()
+		if err != nil {
+			return err
+		}
+		jobs, err := conn.ListJobs(filter)
+		if err != nil {
+			return err
+		}
+		for _, j := range jobs {
+			if j.Status != "OUTPUT" {
+				jobids = append(jobids, j.JobID)
+			}
+		}
+		if len(jobids) == 0 {
+			fmt.Println("No unfinished jobs to watch")
+			return nil
+		}
+	}
+
+	ctx := cmd.Context()
+	if watchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, watchTimeout)
+		defer cancel()
+	}
+
+	fmt.Printf("Watching %s\n", strings.Join(jobids, ", "))
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []string
+	)
+	for _, jobid := range jobids {
+		wg.Add(1)
+		go func(jobid string) {
+			defer wg.Done()
+			status, err := connection.WaitForJob(ctx, conn, jobid, nil)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", jobid, err)
+				mu.Lock()
+				failed = append(failed, jobid)
+				mu.Unlock()
+				return
+			}
+
+			mu.Lock()
+			fmt.Printf("%s %s %s completed — %s\n", time.Now().Format(time.TimeOnly), status.JobName, jobid, status.RetCode)
+			mu.Unlock()
+
+			if !watchNoNotify {
+				notifyJob(ctx, status)
+			}
+		}(jobid)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("stopped watching %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// notifyJob runs the notify hooks of the current profile for a finished job.
+// Hook failures are reported but do not fail the command.
+func notifyJob(ctx context.Context, status *connection.JobStatus) {
+	profile, err := GetCurrentProfile()
+	if err != nil {
+		return
+	}
+	// Hooks still run after Ctrl-C stopped the wait for other jobs
+	ctx = context.WithoutCancel(ctx)
+
+	ev := notify.NewEvent(cfg.DefaultProfile, profile.Host, status)
+	for _, err := range notify.Run(ctx, profile.Notify, ev) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// Job list changes highlighted by zm jobs --watch.
+type jobChange int
+
+const (
+	jobUnchanged jobChange = iota
+	jobNew
+	jobTransition
+	jobFailed
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiClear  = "\033[H\033[2J"
+
+	// maxWatchEvents is the number of recent transitions shown below the table.
+	maxWatchEvents = 10
+)
+
+// diffJobs compares jobs with the previous refresh. On the first refresh
+// (prev is nil) nothing is new and only failures are flagged. It also returns
+// a line for every job that appeared or changed status.
+func diffJobs(prev map[string]connection.JobStatus, jobs []connection.JobStatus) ([]jobChange, []string) {
+	changes := make([]jobChange, len(jobs))
+	var events []string
+	for i, j := range jobs {
+		old, seen := prev[j.JobID]
+		switch {
+		case prev != nil && !seen:
+			changes[i] = jobNew
+			events = append(events, fmt.Sprintf("%s %s appeared (%s)", j.JobName, j.JobID, j.Status))
+		case seen && (old.Status != j.Status || old.RetCode != j.RetCode):
+			changes[i] = jobTransition
+			event := fmt.Sprintf("%s %s %s -> %s", j.JobName, j.JobID, old.Status, j.Status)
+			if j.RetCode != "" {
+				event += " " + j.RetCode
+			}
+			events = append(events, event)
+		}
+		// Failures stay highlighted for as long as the job is listed
+		if connection.MatchRetCode(j.RetCode, "failed") {
+			changes[i] = jobFailed
+		}
+	}
+	return changes, events
+}
+
+// watchJobList redraws the job list every jobsEvery until ctx is cancelled.
+func watchJobList(ctx context.Context, conn connection.Connection, filter connection.JobFilter, cat *explain.Catalog) error {
+	if jobsEvery < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+
+	info, err := os.Stdout.Stat()
+	tty := err == nil && info.Mode()&os.ModeCharDevice != 0
+
+	var (
+		prev   map[string]connection.JobStatus
+		events []string
+	)
+	for {
+		jobs, err := conn.ListJobs(filter)
+
+		var screen strings.Builder
+		if tty {
+			screen.WriteString(ansiClear)
+		}
+		fmt.Fprintf(&screen, "Every %s: zm jobs    %s\n\n", jobsEvery, time.Now().Format(time.DateTime))
+
+		if err != nil {
+			fmt.Fprintf(&screen, "Error: %v\n", err)
+		} else {
+			changes, changed := diffJobs(prev, jobs)
+			stamp := time.Now().Format(time.TimeOnly)
+			for _, e := range changed {
+				events = append(events, stamp+" "+e)
+			}
+			if len(events) > maxWatchEvents {
+				events = events[len(events)-maxWatchEvents:]
+			}
+
+			renderJobList(&screen, jobs, changes, cat, tty)
+			if len(events) > 0 {
+				screen.WriteString("\n")
+				for _, e := range events {
+					screen.WriteString(e + "\n")
+				}
+			}
+
+			prev = make(map[string]connection.JobStatus, len(jobs))
+			for _, j := range jobs {
+				prev[j.JobID] = j
+			}
+		}
+		fmt.Print(screen.String())
+
+		timer := time.NewTimer(jobsEvery)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// renderJobList prints the job table with a marker in front of each job
+// (+ new, ~ changed, ! failed), coloured when writing to a terminal.
+func renderJobList(out *strings.Builder, jobs []connection.JobStatus, changes []jobChange, cat *explain.Catalog, color bool) {
+	if len(jobs) == 0 {
+		out.WriteString("No jobs found\n")
+		return
+	}
+
+	var table bytes.Buffer
+	printJobList(&table, jobs, cat)
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+
+	out.WriteString("  " + lines[0] + "\n")
+	for i, line := range lines[1:] {
+		marker, code := "  ", ""
+		switch changes[i] {
+		case jobNew:
+			marker, code = "+ ", ansiGreen
+		case jobTransition:
+			marker, code = "~ ", ansiYellow
+		case jobFailed:
+			marker, code = "! ", ansiRed
+		}
+		if color && code != "" {
+			out.WriteString(code + marker + line + ansiReset + "\n")
+		} else {
+			out.WriteString(marker + line + "\n")
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"zm/internal/connection"
+)
+
+func TestDiffJobs(t *testing.T) {
+	prev := map[string]connection.JobStatus{
+		"JOB00001": {JobID: "JOB00001", JobName: "PAYROLL", Status: "INPUT"},
+		"JOB00002": {JobID: "JOB00002", JobName: "BACKUP", Status: "ACTIVE"},
+		"JOB00003": {JobID: "JOB00003", JobName: "REPORT", Status: "OUTPUT", RetCode: "CC 0012"},
+	}
+	jobs := []connection.JobStatus{
+		{JobID: "JOB00001", JobName: "PAYROLL", Status: "ACTIVE"},
+		{JobID: "JOB00002", JobName: "BACKUP", Status: "ACTIVE"},
+		{JobID: "JOB00003", JobName: "REPORT", Status: "OUTPUT", RetCode: "CC 0012"},
+		{JobID: "JOB00004", JobName: "EXTRACT", Status: "INPUT"},
+	}
+
+	changes, events := diffJobs(prev, jobs)
+
+	want := []jobChange{jobTransition, jobUnchanged, jobFailed, jobNew}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+	wantEvents := []string{"PAYROLL JOB00001 INPUT -> ACTIVE", "EXTRACT JOB00004 appeared (INPUT)"}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("events = %q, want %q", events, wantEvents)
+	}
+
+	changes, events = diffJobs(nil, jobs)
+	if changes[3] != jobUnchanged || len(events) != 0 {
+		t.Errorf("first refresh should not flag new jobs, got %v %v", changes, events)
+	}
+}
+
+func TestRenderJobList(t *testing.T) {
+	jobs := []connection.JobStatus{
+		{JobID: "JOB00001", JobName: "PAYROLL", Owner: "FALZONE", Status: "OUTPUT", RetCode: "ABEND S0C7"},
+		{JobID: "JOB00002", JobName: "BACKUP", Owner: "FALZONE", Status: "ACTIVE"},
+	}
+
+	var out strings.Builder
+	renderJobList(&out, jobs, []jobChange{jobFailed, jobUnchanged}, nil, false)
+	lines := strings.Split(out.String(), "\n")
+
+	if !strings.HasPrefix(lines[0], "  JOBNAME") {
+		t.Errorf("header = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "! PAYROLL") || !strings.HasPrefix(lines[2], "  BACKUP") {
+		t.Errorf("rows = %q", lines[1:3])
+	}
+	if strings.Contains(out.String(), "\033[") {
+		t.Error("no colour expected when not writing to a terminal")
+	}
+}
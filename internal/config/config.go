@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -21,11 +22,46 @@ type Profile struct {
 	Protocol string `yaml:"protocol"` // zosmf, ftp
 	HLQ      string `yaml:"hlq"`
 	USSHome  string `yaml:"uss_home"`
+	Notify   []Hook `yaml:"notify,omitempty"`
+	TLS      *TLS   `yaml:"tls,omitempty"`
+	FTP      *FTP   `yaml:"ftp,omitempty"`
+}
+
+// FTP holds settings that only apply to the ftp protocol.
+type FTP struct {
+	Active         bool `yaml:"active,omitempty"`           // server connects back (PORT/EPRT)
+	IgnorePASVHost bool `yaml:"ignore_pasv_host,omitempty"` // use the host address for PASV data connections (NAT)
+	PoolSize       int  `yaml:"pool_size,omitempty"`        // sessions open at once, default 4
+	Compress       bool `yaml:"compress,omitempty"`         // deflate spool and listings (MODE Z) if the server supports it
+}
+
+// TLS secures a profile. For ftp, explicit mode sends AUTH TLS on the normal
+// port and implicit speaks TLS from the start (port 990 by default); zosmf
+// always uses TLS and logs in with the client certificate if one is set.
+type TLS struct {
+	Mode         string `yaml:"mode,omitempty"`          // ftp: none (default), explicit or implicit
+	CAFile       string `yaml:"ca_file,omitempty"`       // PEM CA bundle, default: system roots
+	CertFile     string `yaml:"cert_file,omitempty"`     // PEM client certificate, or a PKCS#12 bundle (.p12, .pfx)
+	KeyFile      string `yaml:"key_file,omitempty"`      // PEM private key of cert_file
+	CertPassword string `yaml:"cert_password,omitempty"` // password of a PKCS#12 cert_file
+	ServerName   string `yaml:"server_name,omitempty"`   // name in the server certificate, default: host
+	Fingerprint  string `yaml:"fingerprint,omitempty"`   // SHA-256 of the server certificate, trusted without CA
+	Insecure     bool   `yaml:"insecure,omitempty"`      // skip certificate verification
+}
+
+// Hook is run when a job finishes, see internal/notify. Exactly one of
+// Command, Desktop and Webhook is set.
+type Hook struct {
+	Command string `yaml:"command,omitempty"` // shell command
+	Desktop bool   `yaml:"desktop,omitempty"` // desktop notification
+	Webhook string `yaml:"webhook,omitempty"` // URL receiving the job status as a JSON POST
+	On      string `yaml:"on,omitempty"`      // always (default), success or failure
 }
 
 type Config struct {
 	Profiles       map[string]*Profile `yaml:"profiles"`
 	DefaultProfile string              `yaml:"default_profile"`
+	Catalog        string              `yaml:"catalog,omitempty"` // extra abend/message explanations
 }
 
 func Load(path string) (*Config, error) {
@@ -56,6 +92,9 @@ func Load(path string) (*Config, error) {
 		}
 		if p.Port == 0 {
 			p.Port = DefaultPortForProtocol(p.Protocol)
+			if p.Protocol == "ftp" && p.TLS != nil && p.TLS.Mode == "implicit" {
+				p.Port = 990
+			}
 		}
 		if err := p.Validate(); err != nil {
 			return nil, fmt.Errorf("profile '%s': %w", name, err)
@@ -88,6 +127,23 @@ func (c *Config) Save(path string) error {
 	return nil
 }
 
+// CatalogPath returns the explanation catalog path with a leading ~ expanded.
+func (c *Config) CatalogPath() (string, error) {
+	return ExpandHome(c.Catalog)
+}
+
+// ExpandHome expands a leading ~/ in path to the home directory.
+func ExpandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find home directory: %w", err)
+	}
+	return filepath.Join(home, path[2:]), nil
+}
+
 func (c *Config) GetProfile(name string) (*Profile, error) {
 	if name == "" {
 		name = c.DefaultProfile
@@ -111,12 +167,87 @@ func (p *Profile) Validate() error {
 	if p.User == "" {
 		return fmt.Errorf("user is required")
 	}
-	if p.Password == "" {
+	if p.Password == "" && !p.usesClientCertificate() {
 		return fmt.Errorf("password is required")
 	}
 	if p.Protocol != "zosmf" && p.Protocol != "ftp" {
 		return fmt.Errorf("protocol must be 'zosmf' or 'ftp'")
 	}
+	for i, h := range p.Notify {
+		if err := h.Validate(); err != nil {
+			return fmt.Errorf("notify hook %d: %w", i+1, err)
+		}
+	}
+	if p.FTP != nil {
+		if p.Protocol != "ftp" {
+			return fmt.Errorf("ftp settings require protocol 'ftp'")
+		}
+		if p.FTP.PoolSize < 0 {
+			return fmt.Errorf("ftp: invalid pool_size %d", p.FTP.PoolSize)
+		}
+	}
+	if p.TLS != nil {
+		if p.TLS.Mode != "" && p.Protocol != "ftp" {
+			return fmt.Errorf("tls: mode is only supported with protocol 'ftp'")
+		}
+		if err := p.TLS.Validate(); err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+	}
+	return nil
+}
+
+// usesClientCertificate reports whether the profile logs in with a client
+// certificate instead of a password, which z/OSMF supports.
+func (p *Profile) usesClientCertificate() bool {
+	return p.Protocol == "zosmf" && p.TLS != nil && p.TLS.CertFile != ""
+}
+
+func isPKCS12(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".p12", ".pfx":
+		return true
+	}
+	return false
+}
+
+func (t *TLS) Validate() error {
+	switch t.Mode {
+	case "", "none", "explicit", "implicit":
+	default:
+		return fmt.Errorf("mode must be 'none', 'explicit' or 'implicit'")
+	}
+	if t.KeyFile != "" && t.CertFile == "" {
+		return fmt.Errorf("key_file requires cert_file")
+	}
+	if t.CertFile != "" && t.KeyFile == "" && !isPKCS12(t.CertFile) {
+		return fmt.Errorf("key_file is required unless cert_file is a PKCS#12 bundle (.p12, .pfx)")
+	}
+	if t.Insecure && (t.CAFile != "" || t.Fingerprint != "") {
+		return fmt.Errorf("insecure cannot be combined with ca_file or fingerprint")
+	}
+	return nil
+}
+
+func (h Hook) Validate() error {
+	kinds := 0
+	if h.Command != "" {
+		kinds++
+	}
+	if h.Desktop {
+		kinds++
+	}
+	if h.Webhook != "" {
+		kinds++
+	}
+	if kinds != 1 {
+		return fmt.Errorf("set exactly one of command, desktop or webhook")
+	}
+	switch h.On {
+	case "", "always", "success", "failure":
+	default:
+		return fmt.Errorf("on must be 'always', 'success' or 'failure'")
+	}
 	return nil
 }
 
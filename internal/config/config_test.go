@@ -69,6 +69,162 @@ func TestProfileValidate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid notify hooks",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "zosmf",
+				Notify: []Hook{
+					{Command: "echo done"},
+					{Desktop: true, On: "failure"},
+					{Webhook: "http://localhost:9000/hook", On: "success"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "notify hook with two kinds",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "zosmf",
+				Notify:   []Hook{{Command: "echo done", Desktop: true}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "notify hook with invalid on",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "zosmf",
+				Notify:   []Hook{{Desktop: true, On: "sometimes"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ftp with explicit tls",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "ftp",
+				TLS:      &TLS{Mode: "explicit", CAFile: "~/ca.pem", CertFile: "me.pem", KeyFile: "me.key"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "tls with unknown mode",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "ftp",
+				TLS:      &TLS{Mode: "starttls"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tls cert without key",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "ftp",
+				TLS:      &TLS{Mode: "implicit", CertFile: "me.pem"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative ftp pool size",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "ftp",
+				FTP:      &FTP{PoolSize: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ftp settings with zosmf",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "zosmf",
+				FTP:      &FTP{Active: true},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tls with zosmf",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "zosmf",
+				TLS:      &TLS{CAFile: "ca.pem", ServerName: "zosmf.example.com"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "zosmf client certificate without password",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Protocol: "zosmf",
+				TLS:      &TLS{CertFile: "user.p12", CertPassword: "changeit"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ftp client certificate without password",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Protocol: "ftp",
+				TLS:      &TLS{Mode: "explicit", CertFile: "user.pem", KeyFile: "user.key"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tls key without cert",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "zosmf",
+				TLS:      &TLS{KeyFile: "user.key"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tls mode with zosmf",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "zosmf",
+				TLS:      &TLS{Mode: "explicit"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "insecure with fingerprint",
+			profile: Profile{
+				Host:     "mainframe.example.com",
+				User:     "user",
+				Password: "pass",
+				Protocol: "zosmf",
+				TLS:      &TLS{Insecure: true, Fingerprint: "ab:cd"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid protocol",
 			profile: Profile{
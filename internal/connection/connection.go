@@ -1,12 +1,35 @@
 package connection
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrPasswordExpired is returned when the logon is rejected because the
+// password has expired. ChangePassword still works then.
+var ErrPasswordExpired = errors.New("password expired")
+
 type JobStatus struct {
-	JobID   string
-	JobName string
-	Owner   string
-	Status  string // ACTIVE, OUTPUT, INPUT
-	RetCode string // CC 0000, ABEND S806, etc.
-	Class   string
+	JobID     string
+	JobName   string
+	Owner     string
+	Status    string // ACTIVE, OUTPUT, INPUT
+	RetCode   string // CC 0000, ABEND S806, etc.
+	Class     string
+	Submitted time.Time // zero if the transport does not report it
+	Started   time.Time
+	Ended     time.Time
+}
+
+// SpoolFile describes one DD of a job's output.
+type SpoolFile struct {
+	ID       int
+	DDName   string
+	StepName string
+	ProcStep string
+	Class    string
+	Bytes    int
+	Records  int
 }
 
 type Member struct {
@@ -38,7 +61,15 @@ type Connection interface {
 
 	// Jobs
 	SubmitJCL(jcl []byte) (string, error) // returns job ID
-	ListJobs(owner string) ([]JobStatus, error)
+	SubmitMember(dataset, member string) (string, error)
+	ListJobs(filter JobFilter) ([]JobStatus, error)
 	GetJobStatus(jobid string) (*JobStatus, error)
 	GetJobOutput(jobid string) ([]byte, error)
+	ListSpoolFiles(jobid string) ([]SpoolFile, error)
+	ReadSpoolFile(jobid string, id int) ([]byte, error)
+	GetJobJCL(jobid string) ([]byte, error) // JCL as submitted (JESJCLIN)
+
+	// ChangePassword sets a new password for the user and uses it from then
+	// on. It must not run concurrently with other calls.
+	ChangePassword(newPassword string) error
 }
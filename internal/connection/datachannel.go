@@ -0,0 +1,237 @@
+package connection
+
+import (
+	"bufio"
+	"compress/zlib"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DataChannel selects how the data connections of job and listing commands
+// are opened. By default the client connects to the port the server offers
+// with EPSV, or PASV when EPSV is not supported. Dataset and USS transfers
+// go through jlaffaye/ftp, which always uses EPSV or PASV.
+type DataChannel struct {
+	// Active makes the server connect back to the client (PORT/EPRT).
+	Active bool
+	// IgnorePASVHost connects to the address of the control connection
+	// instead of the one in the PASV reply, which is wrong behind NAT.
+	IgnorePASVHost bool
+	// Compress sends data deflated (MODE Z) if the server supports it.
+	Compress bool
+}
+
+// dataConn is a data connection being set up. Passive connections are
+// dialed before the transfer command, active ones accepted after it.
+type dataConn struct {
+	conn net.Conn
+	ln   net.Listener
+}
+
+func (c *jesClient) prepareData() (*dataConn, error) {
+	if c.data.Active {
+		return c.listenData()
+	}
+
+	addr, err := c.passiveAddr()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("tcp", addr, ftpTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect data channel: %w", err)
+	}
+	return &dataConn{conn: conn}, nil
+}
+
+// passiveAddr asks the server for a passive data port. EPSV comes first
+// since it works over IPv6 and through NAT.
+func (c *jesClient) passiveAddr() (string, error) {
+	peer := c.conn.RemoteAddr().(*net.TCPAddr)
+
+	if !c.noEPSV {
+		resp, err := c.cmdResp("EPSV")
+		if err == nil {
+			port, err := parseEPSV(resp)
+			if err != nil {
+				return "", err
+			}
+			return net.JoinHostPort(peer.IP.String(), strconv.Itoa(port)), nil
+		}
+		if isSessionError(err) {
+			return "", err
+		}
+		c.noEPSV = true
+	}
+
+	resp, err := c.cmdResp("PASV")
+	if err != nil {
+		return "", err
+	}
+	addr, err := parsePASV(resp)
+	if err != nil {
+		return "", err
+	}
+	if c.data.IgnorePASVHost {
+		_, port, _ := net.SplitHostPort(addr)
+		return net.JoinHostPort(peer.IP.String(), port), nil
+	}
+	return addr, nil
+}
+
+// listenData opens a port on the local address of the control connection
+// and tells the server with PORT, or EPRT for IPv6.
+func (c *jesClient) listenData() (*dataConn, error) {
+	local := c.conn.LocalAddr().(*net.TCPAddr)
+	ln, err := net.Listen("tcp", net.JoinHostPort(local.IP.String(), "0"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data port: %w", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	if ip := local.IP.To4(); ip != nil {
+		err = c.cmd("PORT %d,%d,%d,%d,%d,%d", ip[0], ip[1], ip[2], ip[3], port/256, port%256)
+	} else {
+		err = c.cmd("EPRT |2|%s|%d|", local.IP, port)
+	}
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return &dataConn{ln: ln}, nil
+}
+
+// open returns the data connection once the server accepted the transfer,
+// completing the TLS handshake when the channel is protected (also for
+// empty uploads, where no Write would start it).
+func (d *dataConn) open(cfg *tls.Config) (net.Conn, error) {
+	if d.ln != nil {
+		d.ln.(*net.TCPListener).SetDeadline(time.Now().Add(ftpTimeout))
+		conn, err := d.ln.Accept()
+		d.ln.Close()
+		d.ln = nil
+		if err != nil {
+			return nil, fmt.Errorf("server did not open the data channel: %w", err)
+		}
+		d.conn = conn
+	}
+
+	if cfg != nil {
+		tc := tls.Client(d.conn, cfg)
+		tc.SetDeadline(time.Now().Add(ftpTimeout))
+		if err := tc.Handshake(); err != nil {
+			return nil, fmt.Errorf("TLS handshake on data channel failed: %w", err)
+		}
+		tc.SetDeadline(time.Time{}) // the transfer sets its own
+		d.conn = tc
+	}
+	return d.conn, nil
+}
+
+func (d *dataConn) close() {
+	if d.ln != nil {
+		d.ln.Close()
+		d.ln = nil
+	}
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+}
+
+// parseEPSV returns the port of a reply like
+// 229 Entering Extended Passive Mode (|||6446|).
+func parseEPSV(resp string) (int, error) {
+	start := strings.Index(resp, "(")
+	end := strings.LastIndex(resp, ")")
+	if start == -1 || end < start+2 {
+		return 0, fmt.Errorf("invalid EPSV response: %s", resp)
+	}
+
+	inner := resp[start+1 : end]
+	parts := strings.Split(inner, inner[:1])
+	if len(parts) != 5 {
+		return 0, fmt.Errorf("invalid EPSV response: %s", resp)
+	}
+	port, err := strconv.Atoi(parts[3])
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid EPSV port: %s", resp)
+	}
+	return port, nil
+}
+
+// enableDeflate switches the session to MODE Z. Servers without it keep
+// sending uncompressed data.
+func (c *jesClient) enableDeflate() error {
+	if err := c.cmd("MODE Z"); err != nil {
+		if isSessionError(err) {
+			return err
+		}
+		return nil
+	}
+	c.deflate = true
+	return nil
+}
+
+// readData reads the data of a transfer, inflating it in MODE Z.
+func (c *jesClient) readData(conn net.Conn) ([]byte, error) {
+	if !c.deflate {
+		return io.ReadAll(conn)
+	}
+	r := bufio.NewReader(conn)
+	if _, err := r.Peek(1); err == io.EOF {
+		return nil, nil // nothing to inflate
+	}
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// writeData sends the data of a transfer, deflated in MODE Z.
+func (c *jesClient) writeData(conn net.Conn, data []byte) error {
+	if !c.deflate {
+		_, err := conn.Write(data)
+		return err
+	}
+	zw := zlib.NewWriter(conn)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// idleChunk is how much idleConn writes per deadline.
+const idleChunk = 32 << 10
+
+// idleConn moves the deadline forward on every read and write, so that a
+// transfer only fails when it stalls for timeout, however long it runs.
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c idleConn) Read(p []byte) (int, error) {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Read(p)
+}
+
+func (c idleConn) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+		n, err := c.Conn.Write(p[written:min(len(p), written+idleChunk)])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,151 @@
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEPSV(t *testing.T) {
+	tests := []struct {
+		resp    string
+		want    int
+		wantErr bool
+	}{
+		{"229 Entering Extended Passive Mode (|||6446|)", 6446, false},
+		{"229 Entering Extended Passive Mode (!!!1025!)", 1025, false},
+		{"229 Entering Extended Passive Mode (|||0|)", 0, true},
+		{"229 Entering Extended Passive Mode (|||port|)", 0, true},
+		{"229 Entering Extended Passive Mode", 0, true},
+		{"229 ()", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEPSV(tt.resp)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEPSV(%q) error = %v, wantErr %v", tt.resp, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseEPSV(%q) = %d, want %d", tt.resp, got, tt.want)
+		}
+	}
+}
+
+func TestFTPDataChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		addr     string
+		noEPSV   bool
+		pasvHost string
+		data     DataChannel
+		want     string // command that opened the data connections
+	}{
+		{name: "epsv", addr: "127.0.0.1:0", want: "EPSV"},
+		{name: "pasv fallback", addr: "127.0.0.1:0", noEPSV: true, want: "PASV"},
+		{name: "pasv behind nat", addr: "127.0.0.1:0", noEPSV: true, pasvHost: "10,255,255,1", data: DataChannel{IgnorePASVHost: true}, want: "PASV"},
+		{name: "active", addr: "127.0.0.1:0", data: DataChannel{Active: true}, want: "PORT"},
+		{name: "ipv6 epsv", addr: "[::1]:0", want: "EPSV"},
+		{name: "ipv6 active", addr: "[::1]:0", data: DataChannel{Active: true}, want: "EPRT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startFakeFTP(t, tt.addr, nil, false)
+			srv.noEPSV = tt.noEPSV
+			srv.pasvHost = tt.pasvHost
+			srv.lists["IBMUSER.JCL"] = " Name     VV.MM   Created       Changed      Size  Init   Mod   Id\r\n" +
+				"MYJOB     01.00 2024/01/01 2024/01/15 09:00    10    10     0 IBMUSER\r\n"
+			srv.lists["JOB00042"] = "MYJOB    JOB00042 IBMUSER  OUTPUT A        RC=0000\r\n"
+
+			conn := srv.connection(WithDataChannel(tt.data))
+			defer conn.Close()
+
+			if _, err := conn.ListMembers("IBMUSER.JCL"); err != nil {
+				t.Fatalf("ListMembers error: %v", err)
+			}
+			if _, err := conn.GetJobStatus("JOB00042"); err != nil {
+				t.Fatalf("GetJobStatus error: %v", err)
+			}
+
+			if n := srv.count(tt.want); n != 2 {
+				t.Errorf("%s sent %d times, want 2", tt.want, n)
+			}
+			if tt.noEPSV {
+				if n := srv.count("EPSV"); n != 1 {
+					t.Errorf("EPSV sent %d times after it was rejected, want 1", n)
+				}
+			}
+		})
+	}
+}
+
+func TestFTPModeZ(t *testing.T) {
+	for _, supported := range []bool{true, false} {
+		t.Run(fmt.Sprintf("supported=%v", supported), func(t *testing.T) {
+			srv := newFakeFTP(t)
+			srv.noModeZ = !supported
+			src := strings.Repeat("//STEP1 EXEC PGM=IEFBR14", 100)
+			srv.files["JOB00042.2"] = src
+			srv.files["JOB00042.3"] = ""
+
+			conn := srv.connection(WithDataChannel(DataChannel{Compress: true}))
+			defer conn.Close()
+
+			if _, err := conn.SubmitJCL([]byte(src)); err != nil {
+				t.Fatalf("SubmitJCL error: %v", err)
+			}
+			got, err := conn.ReadSpoolFile("JOB00042", 2)
+			if err != nil {
+				t.Fatalf("ReadSpoolFile error: %v", err)
+			}
+			if string(got) != src {
+				t.Errorf("ReadSpoolFile = %d bytes, want %d", len(got), len(src))
+			}
+			if out, err := conn.ReadSpoolFile("JOB00042", 3); err != nil || len(out) != 0 {
+				t.Errorf("ReadSpoolFile of an empty file = %q, %v", out, err)
+			}
+
+			if n := srv.count("MODE Z"); n != 1 {
+				t.Errorf("MODE Z sent %d times, want 1", n)
+			}
+		})
+	}
+}
+
+func TestIdleConn(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	// A transfer running several timeouts long succeeds while data flows
+	client, server := net.Pipe()
+	go func() {
+		for i := 0; i < 8; i++ {
+			time.Sleep(timeout / 4)
+			server.Write([]byte("x"))
+		}
+		server.Close()
+	}()
+	data, err := io.ReadAll(idleConn{client, timeout})
+	if err != nil || len(data) != 8 {
+		t.Errorf("slow transfer: read %d bytes, err %v", len(data), err)
+	}
+	client.Close()
+
+	// A stalled one fails
+	client, server = net.Pipe()
+	defer server.Close()
+	defer client.Close()
+	_, err = idleConn{client, timeout}.Read(make([]byte, 1))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("stalled read error = %v, want deadline exceeded", err)
+	}
+	_, err = idleConn{client, timeout}.Write([]byte("x"))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("stalled write error = %v, want deadline exceeded", err)
+	}
+}
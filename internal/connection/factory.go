@@ -2,12 +2,12 @@ package connection
 
 import "fmt"
 
-func NewConnection(host string, port int, user, password, protocol string) (Connection, error) {
+func NewConnection(host string, port int, user, password, protocol string, opts ...Option) (Connection, error) {
 	switch protocol {
 	case "zosmf":
-		return NewZOSMFConnection(host, port, user, password), nil
+		return NewZOSMFConnection(host, port, user, password, opts...), nil
 	case "ftp":
-		return NewFTPConnection(host, port, user, password), nil
+		return NewFTPConnection(host, port, user, password, opts...), nil
 	default:
 		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
 	}
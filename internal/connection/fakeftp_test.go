@@ -0,0 +1,416 @@
+package connection
+
+import (
+	"bufio"
+	"compress/zlib"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeFTP is a minimal z/OS FTP server stand-in for tests. LIST and RETR
+// arguments are looked up in lists and files, a LIST without argument lists
+// the dataset of the last CWD. STOR saves to files, or answers with a fixed
+// job ID in JES mode. After MODE Z data is sent as zlib streams. With tls
+// set it accepts AUTH TLS, or speaks TLS from the start when implicit is set.
+type fakeFTP struct {
+	t  *testing.T
+	ln net.Listener
+
+	tls      *tls.Config
+	implicit bool
+
+	mu       sync.Mutex
+	lists    map[string]string
+	files    map[string]string
+	noEPSV   bool   // reject EPSV
+	pasvHost string // host in PASV replies, e.g. "10,0,0,1"
+	jesLevel int    // JESINTERFACELEVEL, 2 if unset
+	// noStatLevel leaves the level out of STAT
+	noStatLevel bool
+	badOwner    string // JESOWNER value SITE rejects
+	noModeZ     bool   // reject MODE Z
+	password    string // checked by PASS if set
+	expired     bool   // PASS only accepts old/new/new
+	logins      int
+	commands    []string
+	conns       []net.Conn
+}
+
+func newFakeFTP(t *testing.T) *fakeFTP {
+	return startFakeFTP(t, "127.0.0.1:0", nil, false)
+}
+
+func newFakeFTPS(t *testing.T, cfg *tls.Config, implicit bool) *fakeFTP {
+	return startFakeFTP(t, "127.0.0.1:0", cfg, implicit)
+}
+
+func startFakeFTP(t *testing.T, addr string, cfg *tls.Config, implicit bool) *fakeFTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("listen on %s: %v", addr, err)
+	}
+	s := &fakeFTP{
+		t:        t,
+		ln:       ln,
+		tls:      cfg,
+		implicit: implicit,
+		lists:    make(map[string]string),
+		files:    make(map[string]string),
+	}
+	t.Cleanup(func() {
+		ln.Close()
+		s.dropSessions()
+	})
+	go s.serve()
+	return s
+}
+
+// connection returns an FTPConnection logging in as IBMUSER.
+func (s *fakeFTP) connection(opts ...Option) *FTPConnection {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	return NewFTPConnection(addr.IP.String(), addr.Port, "IBMUSER", "secret", opts...)
+}
+
+// dropSessions closes every control connection, as an idle timeout would.
+func (s *fakeFTP) dropSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeFTP) loginCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+func (s *fakeFTP) count(prefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, c := range s.commands {
+		if strings.HasPrefix(c, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *fakeFTP) file(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.files[name]
+}
+
+func (s *fakeFTP) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go s.session(conn)
+	}
+}
+
+func (s *fakeFTP) session(conn net.Conn) {
+	defer conn.Close()
+	if s.implicit {
+		conn = tls.Server(conn, s.tls)
+	}
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...any) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+
+	reply("220 fake z/OS FTP server ready")
+	var (
+		protected bool
+		deflate   bool
+		jes       bool
+		cwd       string
+		data      *fakeData
+	)
+	defer func() {
+		if data != nil {
+			data.close()
+		}
+	}()
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		verb = strings.ToUpper(verb)
+
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		if verb == "PASS" {
+			s.logins++
+		}
+		noEPSV, pasvHost := s.noEPSV, s.pasvHost
+		level, noStatLevel, noModeZ, badOwner := s.jesLevel, s.noStatLevel, s.noModeZ, s.badOwner
+		if level == 0 {
+			level = 2
+		}
+		s.mu.Unlock()
+
+		switch verb {
+		case "AUTH":
+			if s.tls == nil || s.implicit {
+				reply("502 AUTH not supported")
+				continue
+			}
+			reply("234 Security environment established - ready for negotiation")
+			conn = tls.Server(conn, s.tls)
+			r = bufio.NewReader(conn)
+		case "PBSZ":
+			reply("200 PBSZ=0")
+		case "PROT":
+			protected = strings.EqualFold(arg, "P")
+			reply("200 Data connection protection set")
+		case "USER":
+			reply("331 Send password please.")
+		case "PASS":
+			if msg := s.logon(arg); msg != "" {
+				reply("%s", msg)
+				continue
+			}
+			reply("230 IBMUSER is logged on.")
+		case "CWD":
+			cwd = strings.Trim(arg, "'")
+			if _, ok := s.lookup("LIST", cwd); ok {
+				reply("250 The working directory \"%s\" is a partitioned data set", cwd)
+			} else {
+				reply("250 \"'%s.'\" is the working directory name prefix.", cwd)
+			}
+		case "SITE":
+			if strings.HasPrefix(arg, "FILETYPE=") {
+				jes = arg == "FILETYPE=JES"
+			}
+			if level == 1 && strings.HasPrefix(arg, "JES") {
+				reply("501 Unrecognized parameter %s on SITE command", arg)
+				continue
+			}
+			if badOwner != "" && arg == "JESOWNER="+badOwner {
+				reply("501 JESOWNER value %s is not valid", badOwner)
+				continue
+			}
+			reply("200 SITE command was accepted")
+		case "STAT":
+			reply("211-Server FTP talking to host, port")
+			if jes && !noStatLevel {
+				reply("211-JESINTERFACELEVEL is %d", level)
+			}
+			reply("211 *** end of status ***")
+		case "TYPE", "NOOP":
+			reply("200 OK")
+		case "MODE":
+			if arg == "Z" && noModeZ {
+				reply("504 Data transfer mode Z not supported")
+				continue
+			}
+			deflate = arg == "Z"
+			reply("200 Data transfer mode is %s", arg)
+		case "EPSV", "PASV":
+			if verb == "EPSV" && noEPSV {
+				reply("500 unknown command EPSV")
+				continue
+			}
+			if data != nil {
+				data.close()
+			}
+			local := conn.LocalAddr().(*net.TCPAddr)
+			ln, err := net.Listen("tcp", net.JoinHostPort(local.IP.String(), "0"))
+			if err != nil {
+				reply("425 Cannot open data connection")
+				continue
+			}
+			data = &fakeData{ln: ln}
+			port := ln.Addr().(*net.TCPAddr).Port
+			if verb == "EPSV" {
+				reply("229 Entering Extended Passive Mode (|||%d|)", port)
+				continue
+			}
+			host := pasvHost
+			if host == "" {
+				host = strings.ReplaceAll(local.IP.String(), ".", ",")
+			}
+			reply("227 Entering Passive Mode (%s,%d,%d)", host, port/256, port%256)
+		case "PORT", "EPRT":
+			addr, err := parseActiveAddr(verb, arg)
+			if err != nil {
+				reply("501 %v", err)
+				continue
+			}
+			if data != nil {
+				data.close()
+			}
+			data = &fakeData{addr: addr}
+			reply("200 Port request OK")
+		case "LIST", "RETR":
+			if verb == "LIST" && arg == "" {
+				arg = cwd
+			}
+			content, ok := s.lookup(verb, arg)
+			if !ok {
+				reply("550 %s not found", arg)
+				continue
+			}
+			reply("125 Sending data set")
+			dc, err := data.open(s.tls, protected)
+			if err != nil {
+				reply("425 Cannot open data connection")
+				continue
+			}
+			if deflate {
+				zw := zlib.NewWriter(dc)
+				io.WriteString(zw, content)
+				zw.Close()
+			} else {
+				io.WriteString(dc, content)
+			}
+			dc.Close()
+			reply("250 Transfer completed")
+		case "STOR":
+			reply("125 Storing data set")
+			dc, err := data.open(s.tls, protected)
+			if err != nil {
+				reply("425 Cannot open data connection")
+				continue
+			}
+			var content []byte
+			if deflate {
+				zr, err := zlib.NewReader(dc)
+				if err == nil {
+					content, err = io.ReadAll(zr)
+				}
+				if err != nil {
+					dc.Close()
+					reply("451 Inflate failed: %v", err)
+					continue
+				}
+			} else {
+				content, _ = io.ReadAll(dc)
+			}
+			dc.Close()
+			if jes {
+				reply("250 It is known to JES as JOB00042")
+				continue
+			}
+			s.mu.Lock()
+			s.files[arg] = string(content)
+			s.mu.Unlock()
+			reply("250 Transfer completed successfully.")
+		case "QUIT":
+			reply("221 Quit command received. Goodbye.")
+			return
+		default:
+			reply("502 %s not implemented", verb)
+		}
+	}
+}
+
+// fakeData is the data connection of the next transfer: a passive listener
+// or the client's address in active mode.
+type fakeData struct {
+	ln   net.Listener
+	addr string
+}
+
+func (d *fakeData) open(cfg *tls.Config, protected bool) (net.Conn, error) {
+	if d == nil {
+		return nil, fmt.Errorf("no PASV or PORT before transfer")
+	}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if d.ln != nil {
+		conn, err = d.ln.Accept()
+	} else {
+		conn, err = net.Dial("tcp", d.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if protected {
+		conn = tls.Server(conn, cfg)
+	}
+	return conn, nil
+}
+
+func (d *fakeData) close() {
+	if d.ln != nil {
+		d.ln.Close()
+	}
+}
+
+// parseActiveAddr parses the arguments of PORT h1,h2,h3,h4,p1,p2 and
+// EPRT |2|::1|port|.
+func parseActiveAddr(verb, arg string) (string, error) {
+	if verb == "EPRT" {
+		parts := strings.Split(arg, arg[:1])
+		if len(parts) != 5 {
+			return "", fmt.Errorf("bad EPRT %q", arg)
+		}
+		return net.JoinHostPort(parts[2], parts[3]), nil
+	}
+	parts := strings.Split(arg, ",")
+	if len(parts) != 6 {
+		return "", fmt.Errorf("bad PORT %q", arg)
+	}
+	p1, _ := strconv.Atoi(parts[4])
+	p2, _ := strconv.Atoi(parts[5])
+	return net.JoinHostPort(strings.Join(parts[:4], "."), strconv.Itoa(p1*256+p2)), nil
+}
+
+// logon checks a PASS argument as RACF would, changing the password for
+// old/new/new, and returns the error reply if it fails.
+func (s *fakeFTP) logon(arg string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, change := arg, ""
+	if parts := strings.Split(arg, "/"); len(parts) == 3 {
+		if parts[1] != parts[2] {
+			return "530 PASS command failed - new passwords do not match"
+		}
+		old, change = parts[0], parts[1]
+	}
+	if s.password != "" && old != s.password {
+		return "530 PASS command failed"
+	}
+	if change != "" {
+		s.password, s.expired = change, false
+	}
+	if s.expired {
+		return "530 PASS command failed - password has expired"
+	}
+	return ""
+}
+
+func (s *fakeFTP) lookup(verb, arg string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if verb == "LIST" {
+		content, ok := s.lists[arg]
+		return content, ok
+	}
+	content, ok := s.files[arg]
+	return content, ok
+}
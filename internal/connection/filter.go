@@ -0,0 +1,152 @@
+package connection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JobFilter narrows down ListJobs. Zero values mean no filtering.
+type JobFilter struct {
+	Owner   string    // default: current user, "*" for all
+	Prefix  string    // job name prefix, e.g. "PAY" or "PAY*"
+	Status  string    // ACTIVE, INPUT or OUTPUT
+	RetCode string    // "failed", "abend" or a comparison such as ">4", ">=8", "0"
+	Class   string    // job class
+	Since   time.Time // submitted at or after
+	MaxJobs int       // maximum number of jobs returned
+}
+
+// Validate normalizes the filter and checks its values.
+func (f *JobFilter) Validate() error {
+	f.Status = strings.ToUpper(f.Status)
+	switch f.Status {
+	case "", "ACTIVE", "INPUT", "OUTPUT":
+	default:
+		return fmt.Errorf("invalid status %q (expected ACTIVE, INPUT or OUTPUT)", f.Status)
+	}
+
+	if f.RetCode != "" {
+		if _, _, err := parseRetCodeExpr(f.RetCode); err != nil {
+			return err
+		}
+	}
+	if f.MaxJobs < 0 {
+		return fmt.Errorf("invalid max jobs %d", f.MaxJobs)
+	}
+
+	f.Prefix = strings.ToUpper(strings.TrimSuffix(f.Prefix, "*"))
+	f.Class = strings.ToUpper(f.Class)
+	return nil
+}
+
+// serverSideOnly reports whether every filter can be applied by the server,
+// in which case MaxJobs can be passed along as well.
+func (f JobFilter) serverSideOnly() bool {
+	return f.RetCode == "" && f.Class == "" && f.Since.IsZero()
+}
+
+// Match reports whether job passes the filter. Filters the server already
+// applied are checked again, which is harmless.
+func (f JobFilter) Match(job JobStatus) bool {
+	if f.Prefix != "" && !strings.HasPrefix(job.JobName, f.Prefix) {
+		return false
+	}
+	if f.Status != "" && job.Status != f.Status {
+		return false
+	}
+	if f.Class != "" && job.Class != f.Class {
+		return false
+	}
+	if !f.Since.IsZero() && (job.Submitted.IsZero() || job.Submitted.Before(f.Since)) {
+		return false
+	}
+	if f.RetCode != "" && !MatchRetCode(job.RetCode, f.RetCode) {
+		return false
+	}
+	return true
+}
+
+// Apply filters jobs and truncates the result to MaxJobs.
+func (f JobFilter) Apply(jobs []JobStatus) []JobStatus {
+	out := jobs[:0]
+	for _, j := range jobs {
+		if f.Match(j) {
+			out = append(out, j)
+		}
+	}
+	if f.MaxJobs > 0 && len(out) > f.MaxJobs {
+		out = out[:f.MaxJobs]
+	}
+	return out
+}
+
+// MatchRetCode checks a return code such as "CC 0008" or "ABEND S0C7" against
+// an expression. "failed" matches anything but CC 0000-0004, "abend" matches
+// abends, and comparisons (">4", ">=8", "<=4", "!=0", "8") match CC values only.
+// Jobs without a return code never match.
+func MatchRetCode(rc, expr string) bool {
+	if rc == "" {
+		return false
+	}
+	cc, isCC := conditionCode(rc)
+
+	switch strings.ToLower(strings.TrimSpace(expr)) {
+	case "failed":
+		return !isCC || cc > 4
+	case "abend":
+		return strings.Contains(rc, "ABEND")
+	}
+
+	op, n, err := parseRetCodeExpr(expr)
+	if err != nil || !isCC {
+		return false
+	}
+	switch op {
+	case ">":
+		return cc > n
+	case ">=":
+		return cc >= n
+	case "<":
+		return cc < n
+	case "<=":
+		return cc <= n
+	case "!=":
+		return cc != n
+	default:
+		return cc == n
+	}
+}
+
+func parseRetCodeExpr(expr string) (op string, n int, err error) {
+	expr = strings.TrimSpace(expr)
+	switch strings.ToLower(expr) {
+	case "failed", "abend":
+		return strings.ToLower(expr), 0, nil
+	}
+
+	for _, candidate := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if strings.HasPrefix(expr, candidate) {
+			op = candidate
+			break
+		}
+	}
+	n, err = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(expr, op)))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid return code filter %q (use failed, abend or a comparison like >4)", expr)
+	}
+	return op, n, nil
+}
+
+// conditionCode extracts the numeric value of a "CC nnnn" return code.
+func conditionCode(rc string) (int, bool) {
+	if !strings.HasPrefix(rc, "CC ") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(rc, "CC ")))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,105 @@
+package connection
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchRetCode(t *testing.T) {
+	tests := []struct {
+		rc   string
+		expr string
+		want bool
+	}{
+		{"CC 0000", "failed", false},
+		{"CC 0004", "failed", false},
+		{"CC 0008", "failed", true},
+		{"ABEND S0C7", "failed", true},
+		{"JCL ERROR", "failed", true},
+		{"", "failed", false},
+		{"ABEND U4038", "abend", true},
+		{"CC 0012", "abend", false},
+		{"CC 0008", ">4", true},
+		{"CC 0004", ">4", false},
+		{"CC 0004", ">=4", true},
+		{"CC 0004", "<=4", true},
+		{"CC 0000", "<4", true},
+		{"CC 0000", "!=0", false},
+		{"CC 0008", "8", true},
+		{"CC 0008", "=8", true},
+		{"ABEND S0C7", ">4", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rc+" "+tt.expr, func(t *testing.T) {
+			if got := MatchRetCode(tt.rc, tt.expr); got != tt.want {
+				t.Errorf("MatchRetCode(%q, %q) = %v, want %v", tt.rc, tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  JobFilter
+		wantErr bool
+	}{
+		{"empty", JobFilter{}, false},
+		{"lower case status", JobFilter{Status: "output"}, false},
+		{"invalid status", JobFilter{Status: "DONE"}, true},
+		{"invalid rc", JobFilter{RetCode: "bad"}, true},
+		{"negative max", JobFilter{MaxJobs: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJobFilterApply(t *testing.T) {
+	day := time.Date(2025, 6, 14, 0, 0, 0, 0, time.UTC)
+	jobs := []JobStatus{
+		{JobName: "PAYROLL1", JobID: "JOB00001", Status: "OUTPUT", RetCode: "CC 0000", Class: "A", Submitted: day},
+		{JobName: "PAYROLL2", JobID: "JOB00002", Status: "OUTPUT", RetCode: "ABEND S0C7", Class: "B", Submitted: day.Add(time.Hour)},
+		{JobName: "BILLING", JobID: "JOB00003", Status: "OUTPUT", RetCode: "CC 0012", Class: "A", Submitted: day.Add(-time.Hour)},
+		{JobName: "PAYROLL3", JobID: "JOB00004", Status: "ACTIVE", Class: "A"},
+	}
+
+	tests := []struct {
+		name   string
+		filter JobFilter
+		want   []string
+	}{
+		{"no filter", JobFilter{}, []string{"JOB00001", "JOB00002", "JOB00003", "JOB00004"}},
+		{"prefix", JobFilter{Prefix: "pay*"}, []string{"JOB00001", "JOB00002", "JOB00004"}},
+		{"status", JobFilter{Status: "active"}, []string{"JOB00004"}},
+		{"failed", JobFilter{RetCode: "failed"}, []string{"JOB00002", "JOB00003"}},
+		{"class", JobFilter{Class: "b"}, []string{"JOB00002"}},
+		{"since", JobFilter{Since: day}, []string{"JOB00001", "JOB00002"}},
+		{"max", JobFilter{Prefix: "PAY", MaxJobs: 2}, []string{"JOB00001", "JOB00002"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); err != nil {
+				t.Fatalf("Validate error: %v", err)
+			}
+			in := append([]JobStatus(nil), jobs...)
+			got := tt.filter.Apply(in)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Apply() returned %d jobs, want %d", len(got), len(tt.want))
+			}
+			for i, j := range got {
+				if j.JobID != tt.want[i] {
+					t.Errorf("job %d = %s, want %s", i, j.JobID, tt.want[i])
+				}
+			}
+		})
+	}
+}
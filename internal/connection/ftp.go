@@ -2,11 +2,15 @@ package connection
 
 import (
 	"bytes"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"net/textproto"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jlaffaye/ftp"
@@ -19,287 +23,350 @@ type FTPConnection struct {
 	port     int
 	user     string
 	password string
-	conn     *ftp.ServerConn
-	debugBuf bytes.Buffer
+
+	opts options
+
+	tlsOnce   sync.Once
+	tlsConfig *tls.Config // nil without TLS
+	tlsErr    error
+
+	// pool holds the raw sessions for job and listing commands, files the
+	// jlaffaye/ftp sessions for dataset and USS transfers. They share their
+	// slots, so that several goroutines can use the connection at once
+	// without opening more than pool_size sessions.
+	pool  *sessionPool[*jesClient]
+	files *sessionPool[*fileSession]
+}
+
+// fileSession is a jlaffaye/ftp session for dataset and USS transfers.
+type fileSession struct {
+	conn *ftp.ServerConn
+}
+
+func (s *fileSession) close() {
+	s.conn.Quit()
 }
 
-func NewFTPConnection(host string, port int, user, password string) *FTPConnection {
-	return &FTPConnection{
+func NewFTPConnection(host string, port int, user, password string, opts ...Option) *FTPConnection {
+	f := &FTPConnection{
 		host:     host,
 		port:     port,
 		user:     user,
 		password: password,
+		opts:     newOptions(opts),
 	}
+	slots := newPoolSlots(f.opts.poolSize)
+	f.pool = newSessionPool(slots, f.openSession)
+	f.files = newSessionPool(slots, f.openFileSession)
+	return f
 }
 
+// Connect logs in, so that wrong credentials are reported right away.
 func (f *FTPConnection) Connect() error {
-	addr := net.JoinHostPort(f.host, strconv.Itoa(f.port))
-
-	conn, err := ftp.Dial(addr, ftp.DialWithTimeout(ftpTimeout), ftp.DialWithDebugOutput(&f.debugBuf))
-	if err != nil {
-		return fmt.Errorf("failed to connect to %s: %w", addr, err)
-	}
-
-	if err := conn.Login(f.user, f.password); err != nil {
-		conn.Quit()
-		return fmt.Errorf("login failed: %w", err)
-	}
+	return f.withSession(true, func(*jesClient) error { return nil })
+}
 
-	f.conn = conn
-	return nil
+// initTLS builds the TLS configuration shared by the sessions.
+func (f *FTPConnection) initTLS() error {
+	f.tlsOnce.Do(func() {
+		switch f.opts.tls.Mode {
+		case TLSNone:
+			return
+		case TLSExplicit, TLSImplicit:
+		default:
+			f.tlsErr = fmt.Errorf("unknown TLS mode %q", f.opts.tls.Mode)
+			return
+		}
+		f.tlsConfig, f.tlsErr = f.opts.tls.config(f.host)
+	})
+	return f.tlsErr
 }
 
 func (f *FTPConnection) Close() error {
-	if f.conn != nil {
-		if err := f.conn.Quit(); err != nil {
-			return fmt.Errorf("failed to close connection: %w", err)
-		}
-		f.conn = nil
-	}
+	f.pool.close()
+	f.files.close()
 	return nil
 }
 
 func (f *FTPConnection) ListDatasets(pattern string) ([]string, error) {
-	if f.conn == nil {
-		return nil, fmt.Errorf("not connected")
-	}
-
-	// z/OS FTP: list datasets matching pattern (e.g., 'USERNAME.*')
-	query := fmt.Sprintf("'%s.*'", pattern)
-	entries, err := f.conn.NameList(query)
+	var datasets []string
+	err := f.withSession(true, func(c *jesClient) error {
+		var err error
+		datasets, err = c.listDatasets(pattern + ".*")
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list datasets: %w", err)
 	}
-
-	datasets := make([]string, 0, len(entries))
-	for _, e := range entries {
-		name := strings.TrimSpace(e)
-		if name != "" {
-			datasets = append(datasets, name)
-		}
-	}
 	return datasets, nil
 }
 
 func (f *FTPConnection) ListMembers(dataset string) ([]Member, error) {
-	if f.conn == nil {
-		return nil, fmt.Errorf("not connected")
-	}
-
 	dsn := strings.Trim(dataset, "'")
-
-	// Reset debug buffer and capture only this LIST operation
-	f.debugBuf.Reset()
-
-	// cd to PDS
-	if err := f.conn.ChangeDir(fmt.Sprintf("'%s'", dsn)); err != nil {
-		return nil, fmt.Errorf("failed to access dataset %s: %w", dsn, err)
+	var members []Member
+	err := f.withSession(true, func(c *jesClient) error {
+		var err error
+		members, err = c.listMembers(dsn)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of %s: %w", dsn, err)
 	}
+	return members, nil
+}
 
-	// Call List - it will fail to parse but debug output will have the raw data
-	f.conn.List("")
-
-	// Parse the debug output to extract member info
-	return f.parseMemberListFromDebug(f.debugBuf.String())
+func (f *FTPConnection) ReadMember(dataset, member string) ([]byte, error) {
+	// z/OS FTP: retrieve 'DATASET(MEMBER)'
+	return f.ReadFile(fmt.Sprintf("'%s(%s)'", strings.Trim(dataset, "'"), member))
 }
 
-func (f *FTPConnection) parseMemberListFromDebug(debug string) ([]Member, error) {
-	lines := strings.Split(debug, "\n")
-	members := make([]Member, 0, len(lines)/2)
+func (f *FTPConnection) WriteMember(dataset, member string, content []byte) error {
+	return f.WriteFile(fmt.Sprintf("'%s(%s)'", strings.Trim(dataset, "'"), member), content)
+}
 
-	inList := false
-	for _, line := range lines {
-		// Look for lines after "125 List started" until "250 List completed"
-		if strings.Contains(line, "125 List started") {
-			inList = true
-			continue
-		}
-		if strings.Contains(line, "250 List completed") {
-			break
-		}
-		if !inList {
-			continue
+// ReadFile reads a USS file or a quoted dataset name, converted to ASCII.
+func (f *FTPConnection) ReadFile(path string) ([]byte, error) {
+	var data []byte
+	err := f.withFiles(func(s *fileSession) error {
+		if err := s.conn.Type(ftp.TransferTypeASCII); err != nil {
+			return fmt.Errorf("failed to set ASCII mode: %w", err)
 		}
-
-		// Skip header line
-		if strings.Contains(line, "Name") && strings.Contains(line, "VV.MM") {
-			continue
+		r, err := s.conn.Retr(path)
+		if err != nil {
+			return err
 		}
-
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+		data, err = io.ReadAll(r)
+		if cerr := transferError(r.Close()); err == nil {
+			err = cerr
 		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
 
-		member := parseMemberLine(line)
-		if member.Name != "" {
-			members = append(members, member)
+func (f *FTPConnection) WriteFile(path string, content []byte) error {
+	err := f.withFiles(func(s *fileSession) error {
+		if err := s.conn.Type(ftp.TransferTypeASCII); err != nil {
+			return fmt.Errorf("failed to set ASCII mode: %w", err)
 		}
+		return transferError(s.conn.Stor(path, bytes.NewReader(content)))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
-
-	return members, nil
+	return nil
 }
 
-func parseMemberLine(line string) Member {
-	// Format: Name     VV.MM   Created       Changed      Size  Init   Mod   Id
-	// Example: HSISAPIE  01.82 2024/04/16 2025/12/10 20:18     5    27     0 FALZONE
-	fields := strings.Fields(line)
-	if len(fields) < 8 {
-		return Member{}
+// transferError drops the error jlaffaye/ftp reports when z/OS ends a
+// transfer with "250 Transfer completed" instead of 226.
+func transferError(err error) error {
+	if err == nil {
+		return nil
 	}
-
-	m := Member{Name: fields[0]}
-
-	// Parse VV.MM
-	if vvmm := strings.Split(fields[1], "."); len(vvmm) == 2 {
-		m.VV, _ = strconv.Atoi(vvmm[0])
-		m.MM, _ = strconv.Atoi(vvmm[1])
+	errs := []error{err}
+	if m, ok := err.(interface{ WrappedErrors() []error }); ok {
+		errs = m.WrappedErrors()
 	}
-
-	// Created date
-	m.Created = fields[2]
-
-	// Changed date and time
-	if len(fields) >= 5 {
-		m.Changed = fields[3] + " " + fields[4]
+	for _, e := range errs {
+		var reply *textproto.Error
+		if !errors.As(e, &reply) || reply.Code/100 != 2 {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Size, Init, Mod, User
-	if len(fields) >= 6 {
-		m.Size, _ = strconv.Atoi(fields[5])
-	}
-	if len(fields) >= 7 {
-		m.Init, _ = strconv.Atoi(fields[6])
+// withFiles runs fn on a transfer session from the pool. When the session
+// turns out to be gone fn is run once more on a new one, since a transfer
+// of a whole file can be repeated.
+func (f *FTPConnection) withFiles(fn func(*fileSession) error) error {
+	s, fresh, err := f.files.get()
+	if err != nil {
+		return err
 	}
-	if len(fields) >= 8 {
-		m.Mod, _ = strconv.Atoi(fields[7])
+	err = fn(s)
+	if err == nil || !isSessionError(err) {
+		f.files.put(s)
+		return err
 	}
-	if len(fields) >= 9 {
-		m.User = fields[8]
+	if fresh {
+		f.files.discard(s)
+		return err
 	}
 
-	return m
-}
-
-func (f *FTPConnection) ReadMember(dataset, member string) ([]byte, error) {
-	if f.conn == nil {
-		return nil, fmt.Errorf("not connected")
+	if s, err = f.files.reopen(s); err != nil {
+		return err
 	}
-
-	// Set ASCII mode for EBCDIC to ASCII conversion
-	if err := f.conn.Type(ftp.TransferTypeASCII); err != nil {
-		return nil, fmt.Errorf("failed to set ASCII mode: %w", err)
+	if err := fn(s); err != nil {
+		if isSessionError(err) {
+			f.files.discard(s)
+		} else {
+			f.files.put(s)
+		}
+		return err
 	}
+	f.files.put(s)
+	return nil
+}
 
-	// z/OS FTP: retrieve 'DATASET(MEMBER)'
-	dsn := fmt.Sprintf("'%s(%s)'", strings.Trim(dataset, "'"), member)
-	reader, err := f.conn.Retr(dsn)
+// withSession runs fn on a session from the pool, logging in if none is
+// idle. When the session turns out to be gone (idle timeout, dropped
+// connection) fn is run once more on a new session if retry is set;
+// operations that must not run twice check the session with NOOP first
+// instead.
+func (f *FTPConnection) withSession(retry bool, fn func(*jesClient) error) error {
+	jes, fresh, err := f.pool.get()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read %s: %w", dsn, err)
+		return err
+	}
+	if !fresh && !retry {
+		if err := jes.cmd("NOOP"); err != nil {
+			if jes, err = f.pool.reopen(jes); err != nil {
+				return err
+			}
+			fresh = true
+		}
 	}
-	defer reader.Close()
 
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, reader); err != nil {
-		return nil, fmt.Errorf("failed to read content: %w", err)
+	err = fn(jes)
+	if err == nil || !isSessionError(err) {
+		f.pool.put(jes)
+		return err
 	}
-	return buf.Bytes(), nil
-}
-
-func (f *FTPConnection) WriteMember(dataset, member string, content []byte) error {
-	if f.conn == nil {
-		return fmt.Errorf("not connected")
+	if fresh || !retry {
+		f.pool.discard(jes)
+		return err
 	}
 
-	if err := f.conn.Type(ftp.TransferTypeASCII); err != nil {
-		return fmt.Errorf("failed to set ASCII mode: %w", err)
+	if jes, err = f.pool.reopen(jes); err != nil {
+		return err
 	}
-
-	dsn := fmt.Sprintf("'%s(%s)'", strings.Trim(dataset, "'"), member)
-	if err := f.conn.Stor(dsn, bytes.NewReader(content)); err != nil {
-		return fmt.Errorf("failed to write %s: %w", dsn, err)
+	if err := fn(jes); err != nil {
+		if isSessionError(err) {
+			f.pool.discard(jes)
+		} else {
+			f.pool.put(jes)
+		}
+		return err
 	}
+	f.pool.put(jes)
 	return nil
 }
 
-func (f *FTPConnection) ReadFile(path string) ([]byte, error) {
-	if f.conn == nil {
-		return nil, fmt.Errorf("not connected")
-	}
+func (f *FTPConnection) openSession() (*jesClient, error) {
+	return f.login(f.password)
+}
 
-	// Set ASCII mode for EBCDIC to ASCII conversion
-	if err := f.conn.Type(ftp.TransferTypeASCII); err != nil {
-		return nil, fmt.Errorf("failed to set ASCII mode: %w", err)
+func (f *FTPConnection) openFileSession() (*fileSession, error) {
+	if err := f.initTLS(); err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(f.host, strconv.Itoa(f.port))
+	dialOpts := []ftp.DialOption{ftp.DialWithTimeout(ftpTimeout)}
+	switch f.opts.tls.Mode {
+	case TLSExplicit:
+		dialOpts = append(dialOpts, ftp.DialWithExplicitTLS(f.tlsConfig))
+	case TLSImplicit:
+		dialOpts = append(dialOpts, ftp.DialWithTLS(f.tlsConfig))
 	}
 
-	reader, err := f.conn.Retr(path)
+	conn, err := ftp.Dial(addr, dialOpts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
 	}
-	defer reader.Close()
-
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, reader); err != nil {
-		return nil, fmt.Errorf("failed to read content: %w", err)
+	if err := conn.Login(f.user, f.password); err != nil {
+		conn.Quit()
+		return nil, loginError(err)
 	}
-	return buf.Bytes(), nil
+	return &fileSession{conn: conn}, nil
 }
 
-func (f *FTPConnection) WriteFile(path string, content []byte) error {
-	if f.conn == nil {
-		return fmt.Errorf("not connected")
+func (f *FTPConnection) login(password string) (*jesClient, error) {
+	if err := f.initTLS(); err != nil {
+		return nil, err
 	}
+	return newJESClient(jesConfig{
+		addr:     net.JoinHostPort(f.host, strconv.Itoa(f.port)),
+		user:     f.user,
+		password: password,
+		tlsMode:  f.opts.tls.Mode,
+		tls:      f.tlsConfig,
+		data:     f.opts.data,
+	})
+}
 
-	if err := f.conn.Type(ftp.TransferTypeASCII); err != nil {
-		return fmt.Errorf("failed to set ASCII mode: %w", err)
+// ChangePassword logs on with PASS old/new/new, which RACF accepts whether
+// or not the old password has expired. Open sessions stay logged on.
+func (f *FTPConnection) ChangePassword(newPassword string) error {
+	if newPassword == "" || strings.ContainsAny(newPassword, "/\r\n") {
+		return fmt.Errorf("the new password must not be empty or contain '/'")
 	}
-
-	if err := f.conn.Stor(path, bytes.NewReader(content)); err != nil {
-		return fmt.Errorf("failed to write %s: %w", path, err)
+	jes, err := f.login(f.password + "/" + newPassword + "/" + newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
 	}
+	jes.close()
+	f.password = newPassword
 	return nil
 }
 
 func (f *FTPConnection) SubmitJCL(jcl []byte) (string, error) {
-	jes, err := newJESClient(f.host, f.port, f.user, f.password)
+	var jobid string
+	err := f.withSession(false, func(jes *jesClient) error {
+		var err error
+		jobid, err = jes.submitJCL(jcl)
+		return err
+	})
+	return jobid, err
+}
+
+// SubmitMember reads the member and submits it; FTP has no server-side submit.
+func (f *FTPConnection) SubmitMember(dataset, member string) (string, error) {
+	jcl, err := f.ReadMember(dataset, member)
 	if err != nil {
 		return "", err
 	}
-	defer jes.close()
-
-	return jes.submitJCL(jcl)
+	return f.SubmitJCL(jcl)
 }
 
 func (f *FTPConnection) GetJobStatus(jobid string) (*JobStatus, error) {
-	jobs, err := f.ListJobs("")
-	if err != nil {
-		return nil, err
-	}
-
-	for _, job := range jobs {
-		if job.JobID == jobid {
-			return &job, nil
-		}
-	}
-
-	return nil, fmt.Errorf("job %s not found", jobid)
+	var job *JobStatus
+	err := f.withSession(true, func(jes *jesClient) error {
+		var err error
+		job, err = jes.jobStatus(jobid)
+		return err
+	})
+	return job, err
 }
 
-func (f *FTPConnection) ListJobs(owner string) ([]JobStatus, error) {
-	jes, err := newJESClient(f.host, f.port, f.user, f.password)
-	if err != nil {
+func (f *FTPConnection) ListJobs(filter JobFilter) ([]JobStatus, error) {
+	if err := filter.Validate(); err != nil {
 		return nil, err
 	}
-	defer jes.close()
+	if !filter.Since.IsZero() {
+		return nil, fmt.Errorf("filtering by submit time is not supported over FTP")
+	}
 
-	if owner == "" {
-		owner = f.user
+	if filter.Owner == "" {
+		filter.Owner = f.user
 	}
-	if err := jes.setOwner(owner); err != nil {
+
+	var jobs []JobStatus
+	err := f.withSession(true, func(jes *jesClient) error {
+		if err := jes.setFilter(filter); err != nil {
+			return err
+		}
+		var err error
+		jobs, err = jes.listJobs()
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	return jes.listJobs()
+	return filter.Apply(jobs), nil
 }
 
 func parseJobLine(line string) JobStatus {
@@ -326,25 +393,61 @@ func parseJobLine(line string) JobStatus {
 		if strings.HasPrefix(f, "RC=") {
 			job.RetCode = "CC " + strings.TrimPrefix(f, "RC=")
 		} else if strings.HasPrefix(f, "ABEND=") {
-			job.RetCode = "ABEND " + strings.TrimPrefix(f, "ABEND=")
+			code := strings.TrimPrefix(f, "ABEND=")
+			// System abends are reported without the S on some releases
+			if len(code) == 3 {
+				code = "S" + code
+			}
+			job.RetCode = "ABEND " + code
 		}
 	}
+	if strings.Contains(line, "(JCL error)") {
+		job.RetCode = "JCL ERROR"
+	}
 
 	return job
 }
 
 func (f *FTPConnection) GetJobOutput(jobid string) ([]byte, error) {
-	jes, err := newJESClient(f.host, f.port, f.user, f.password)
-	if err != nil {
-		return nil, err
-	}
-	defer jes.close()
+	var out []byte
+	err := f.withSession(true, func(jes *jesClient) error {
+		if err := jes.setOwner(f.user); err != nil {
+			return err
+		}
+		var err error
+		out, err = jes.getJobOutput(jobid)
+		return err
+	})
+	return out, err
+}
 
-	if err := jes.setOwner(f.user); err != nil {
-		return nil, err
-	}
+func (f *FTPConnection) ListSpoolFiles(jobid string) ([]SpoolFile, error) {
+	var files []SpoolFile
+	err := f.withSession(true, func(jes *jesClient) error {
+		var err error
+		files, err = jes.spoolFiles(jobid)
+		return err
+	})
+	return files, err
+}
+
+func (f *FTPConnection) ReadSpoolFile(jobid string, id int) ([]byte, error) {
+	var out []byte
+	err := f.withSession(true, func(jes *jesClient) error {
+		if err := jes.setOwner("*"); err != nil {
+			return err
+		}
+		var err error
+		out, err = jes.getJobOutput(fmt.Sprintf("%s.%d", jobid, id))
+		return err
+	})
+	return out, err
+}
 
-	return jes.getJobOutput(jobid)
+// GetJobJCL is not available over FTP: JES only serves the spool, and
+// JESJCL holds the converted JCL rather than what was submitted.
+func (f *FTPConnection) GetJobJCL(jobid string) ([]byte, error) {
+	return nil, fmt.Errorf("retrieving the submitted JCL of %s is not supported over FTP", jobid)
 }
 
 var _ Connection = (*FTPConnection)(nil)
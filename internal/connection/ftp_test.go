@@ -1,118 +1,12 @@
 package connection
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 )
 
-func TestParseMemberLine(t *testing.T) {
-	tests := []struct {
-		name     string
-		line     string
-		expected Member
-	}{
-		{
-			name: "standard line",
-			line: "HSISAPIE  01.82 2024/04/16 2025/12/10 20:18     5    27     0 FALZONE",
-			expected: Member{
-				Name:    "HSISAPIE",
-				VV:      1,
-				MM:      82,
-				Created: "2024/04/16",
-				Changed: "2025/12/10 20:18",
-				Size:    5,
-				Init:    27,
-				Mod:     0,
-				User:    "FALZONE",
-			},
-		},
-		{
-			name: "different version",
-			line: "MYPROG    02.01 2023/01/01 2024/06/15 10:30   100   100    10 USER123",
-			expected: Member{
-				Name:    "MYPROG",
-				VV:      2,
-				MM:      1,
-				Created: "2023/01/01",
-				Changed: "2024/06/15 10:30",
-				Size:    100,
-				Init:    100,
-				Mod:     10,
-				User:    "USER123",
-			},
-		},
-		{
-			name:     "too few fields",
-			line:     "MEMBER 01.00",
-			expected: Member{},
-		},
-		{
-			name:     "empty line",
-			line:     "",
-			expected: Member{},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := parseMemberLine(tt.line)
-			if got != tt.expected {
-				t.Errorf("parseMemberLine(%q) = %+v, want %+v", tt.line, got, tt.expected)
-			}
-		})
-	}
-}
-
-func TestParseMemberListFromDebug(t *testing.T) {
-	debug := `< 220-FTP server ready
-> USER testuser
-< 331 Password required
-> PASS ****
-< 230 User logged in
-> CWD 'TEST.PDS'
-< 250 Directory changed
-> LIST
-< 125 List started
- Name     VV.MM   Created       Changed      Size  Init   Mod   Id
-PROG1     01.00 2024/01/01 2024/01/15 09:00    10    10     0 USER1
-PROG2     01.05 2024/02/01 2024/03/15 10:00    20    15     5 USER2
-< 250 List completed
-> QUIT
-< 221 Goodbye
-`
-	f := &FTPConnection{}
-	members, err := f.parseMemberListFromDebug(debug)
-	if err != nil {
-		t.Fatalf("parseMemberListFromDebug error: %v", err)
-	}
-
-	if len(members) != 2 {
-		t.Fatalf("expected 2 members, got %d", len(members))
-	}
-
-	if members[0].Name != "PROG1" {
-		t.Errorf("first member = %q, want PROG1", members[0].Name)
-	}
-	if members[1].Name != "PROG2" {
-		t.Errorf("second member = %q, want PROG2", members[1].Name)
-	}
-}
-
-func TestParseMemberListFromDebugEmpty(t *testing.T) {
-	debug := `< 125 List started
- Name     VV.MM   Created       Changed      Size  Init   Mod   Id
-< 250 List completed
-`
-	f := &FTPConnection{}
-	members, err := f.parseMemberListFromDebug(debug)
-	if err != nil {
-		t.Fatalf("error: %v", err)
-	}
-
-	if len(members) != 0 {
-		t.Errorf("expected 0 members, got %d", len(members))
-	}
-}
-
 func TestParseJobLine(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -172,23 +66,84 @@ func TestParseJobLine(t *testing.T) {
 }
 
 func TestParseJobLines(t *testing.T) {
-	lines := []string{
-		"JOBNAME  JOBID    OWNER    STATUS CLASS",
-		"JOB1     JOB00001 USER1    OUTPUT A    RC=0000",
-		"JOB2     JOB00002 USER1    ACTIVE B",
-		"",
+	tests := []struct {
+		name  string
+		level int
+		lines []string
+		want  []JobStatus
+	}{
+		{
+			name:  "level 2",
+			level: 2,
+			lines: []string{
+				"JOBNAME  JOBID    OWNER    STATUS CLASS",
+				"JOB1     JOB00001 USER1    OUTPUT A    RC=0000",
+				"JOB2     JOB00002 USER1    ACTIVE B",
+				"",
+			},
+			want: []JobStatus{
+				{JobName: "JOB1", JobID: "JOB00001", Owner: "USER1", Status: "OUTPUT", Class: "A", RetCode: "CC 0000"},
+				{JobName: "JOB2", JobID: "JOB00002", Owner: "USER1", Status: "ACTIVE", Class: "B"},
+			},
+		},
+		{
+			name:  "level 2 job detail",
+			level: 0,
+			lines: []string{
+				"JOBNAME  JOBID    OWNER    STATUS CLASS",
+				"MYJOB    JOB12345 IBMUSER  OUTPUT A        (JCL error) 3 spool files",
+				"--------",
+				"         ID  STEPNAME PROCSTEP C DDNAME   BYTE-COUNT",
+				"         001 JES2              A JESMSGLG      1200",
+			},
+			want: []JobStatus{
+				{JobName: "MYJOB", JobID: "JOB12345", Owner: "IBMUSER", Status: "OUTPUT", Class: "A", RetCode: "JCL ERROR"},
+			},
+		},
+		{
+			name:  "level 1",
+			level: 1,
+			lines: []string{
+				"IBMUSERA JOB01234  OUTPUT    3 Spool Files",
+				"IBMUSERB JOB01235  ACTIVE",
+			},
+			want: []JobStatus{
+				{JobName: "IBMUSERA", JobID: "JOB01234", Status: "OUTPUT"},
+				{JobName: "IBMUSERB", JobID: "JOB01235", Status: "ACTIVE"},
+			},
+		},
 	}
 
-	jobs := parseJobLines(lines)
-	if len(jobs) != 2 {
-		t.Fatalf("expected 2 jobs, got %d", len(jobs))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseJobLines(tt.lines, tt.level)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d jobs, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("jobs[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
 	}
+}
 
-	if jobs[0].JobName != "JOB1" {
-		t.Errorf("first job name = %q, want JOB1", jobs[0].JobName)
+func TestParseJESLevel(t *testing.T) {
+	tests := []struct {
+		stat string
+		want int
+	}{
+		{"211-JESINTERFACELEVEL is 2\n211-JESOWNER is IBMUSER\n211 *** end of status ***", 2},
+		{"211-JESINTERFACELEVEL is 1", 1},
+		{"211-JESINTERFACELEVEL=2", 2},
+		{"211-FileType SEQ (Sequential - default)\n211 *** end of status ***", 0},
 	}
-	if jobs[1].Status != "ACTIVE" {
-		t.Errorf("second job status = %q, want ACTIVE", jobs[1].Status)
+
+	for _, tt := range tests {
+		if got := parseJESLevel(tt.stat); got != tt.want {
+			t.Errorf("parseJESLevel(%q) = %d, want %d", tt.stat, got, tt.want)
+		}
 	}
 }
 
@@ -225,3 +180,221 @@ func TestParsePASV(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSpoolFileLines(t *testing.T) {
+	lines := []string{
+		"JOBNAME  JOBID    OWNER    STATUS CLASS",
+		"MYJOB    JOB12345 FALZONE  OUTPUT A        RC=0000",
+		"--------",
+		"         ID  STEPNAME PROCSTEP C DDNAME   BYTE-COUNT",
+		"         001 JES2              A JESMSGLG      1200",
+		"         002 JES2              A JESJCL         500",
+		"         003 JES2              A JESYSMSG      1000",
+		"         004 COMPILE  COBOL    A SYSPRINT       300",
+		"4 spool files",
+	}
+
+	files := parseSpoolFileLines(lines)
+	if len(files) != 4 {
+		t.Fatalf("expected 4 spool files, got %d", len(files))
+	}
+
+	want := SpoolFile{ID: 4, StepName: "COMPILE", ProcStep: "COBOL", Class: "A", DDName: "SYSPRINT", Bytes: 300}
+	if files[3] != want {
+		t.Errorf("files[3] = %+v, want %+v", files[3], want)
+	}
+	if files[0].DDName != "JESMSGLG" || files[0].ProcStep != "" {
+		t.Errorf("files[0] = %+v", files[0])
+	}
+
+	// Spool files written outside a step have a blank step name
+	files = parseSpoolFileLines([]string{
+		"         ID  STEPNAME PROCSTEP C DDNAME   BYTE-COUNT",
+		"         005                   H SYSOUT          80",
+	})
+	want = SpoolFile{ID: 5, Class: "H", DDName: "SYSOUT", Bytes: 80}
+	if len(files) != 1 || files[0] != want {
+		t.Errorf("files = %+v, want [%+v]", files, want)
+	}
+}
+
+func TestFTPJESSessionReuse(t *testing.T) {
+	srv := newFakeFTP(t)
+	srv.lists["JOB00042"] = "JOBNAME  JOBID    OWNER    STATUS CLASS\r\nMYJOB    JOB00042 IBMUSER  OUTPUT A        RC=0000\r\n"
+	srv.files["JOB00042.2"] = "//MYJOB JOB\r\n"
+	conn := srv.connection()
+	defer conn.Close()
+
+	jobid, err := conn.SubmitJCL([]byte("//MYJOB JOB\n"))
+	if err != nil {
+		t.Fatalf("SubmitJCL error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		job, err := conn.GetJobStatus(jobid)
+		if err != nil {
+			t.Fatalf("GetJobStatus error: %v", err)
+		}
+		if job.RetCode != "CC 0000" {
+			t.Errorf("RetCode = %q, want CC 0000", job.RetCode)
+		}
+	}
+	if _, err := conn.ReadSpoolFile(jobid, 2); err != nil {
+		t.Fatalf("ReadSpoolFile error: %v", err)
+	}
+
+	if n := srv.loginCount(); n != 1 {
+		t.Errorf("logins = %d, want 1", n)
+	}
+	// Polling the same job does not repeat the filter
+	if n := srv.count("SITE JESOWNER="); n != 1 {
+		t.Errorf("SITE JESOWNER sent %d times, want 1", n)
+	}
+}
+
+func TestFTPJESSessionReconnect(t *testing.T) {
+	srv := newFakeFTP(t)
+	srv.lists["JOB00042"] = "MYJOB    JOB00042 IBMUSER  ACTIVE A\r\n"
+	conn := srv.connection()
+	defer conn.Close()
+
+	if _, err := conn.GetJobStatus("JOB00042"); err != nil {
+		t.Fatalf("GetJobStatus error: %v", err)
+	}
+	srv.dropSessions()
+	if _, err := conn.GetJobStatus("JOB00042"); err != nil {
+		t.Fatalf("GetJobStatus after drop error: %v", err)
+	}
+	srv.dropSessions()
+	if _, err := conn.SubmitJCL([]byte("//MYJOB JOB\n")); err != nil {
+		t.Fatalf("SubmitJCL after drop error: %v", err)
+	}
+
+	if n := srv.loginCount(); n != 3 {
+		t.Errorf("logins = %d, want 3", n)
+	}
+	if n := srv.count("STOR"); n != 1 {
+		t.Errorf("STOR sent %d times, want 1", n)
+	}
+}
+
+func TestFTPJESErrorKeepsSession(t *testing.T) {
+	srv := newFakeFTP(t)
+	conn := srv.connection()
+	defer conn.Close()
+
+	if _, err := conn.GetJobStatus("JOB00001"); err == nil {
+		t.Fatal("expected error for unknown job")
+	}
+	if _, err := conn.GetJobStatus("JOB00002"); err == nil {
+		t.Fatal("expected error for unknown job")
+	}
+	if n := srv.loginCount(); n != 1 {
+		t.Errorf("logins = %d, want 1", n)
+	}
+}
+
+func TestFTPJESLevel1(t *testing.T) {
+	for _, stat := range []bool{true, false} {
+		t.Run(fmt.Sprintf("stat=%v", stat), func(t *testing.T) {
+			srv := newFakeFTP(t)
+			srv.jesLevel = 1
+			srv.noStatLevel = !stat
+			srv.lists[""] = "IBMUSERA JOB00041  OUTPUT    3 Spool Files\r\nMYJOB    JOB00042  ACTIVE\r\n"
+			conn := srv.connection()
+			defer conn.Close()
+
+			jobs, err := conn.ListJobs(JobFilter{Prefix: "MY"})
+			if err != nil {
+				t.Fatalf("ListJobs error: %v", err)
+			}
+			if len(jobs) != 1 || jobs[0].JobID != "JOB00042" {
+				t.Errorf("ListJobs = %+v, want JOB00042 only", jobs)
+			}
+			job, err := conn.GetJobStatus("JOB00041")
+			if err != nil {
+				t.Fatalf("GetJobStatus error: %v", err)
+			}
+			if job.Status != "OUTPUT" {
+				t.Errorf("Status = %q, want OUTPUT", job.Status)
+			}
+			if _, err := conn.ListJobs(JobFilter{Owner: "OTHER"}); err == nil {
+				t.Error("expected error listing jobs of another user")
+			}
+			if _, err := conn.ListJobs(JobFilter{Class: "A"}); err == nil {
+				t.Error("expected error filtering by class")
+			}
+			if _, err := conn.ListJobs(JobFilter{RetCode: "failed"}); err == nil {
+				t.Error("expected error filtering by return code")
+			}
+			if _, err := conn.ListSpoolFiles("JOB00041"); err == nil {
+				t.Error("expected error listing spool files")
+			}
+
+			want := 0
+			if !stat {
+				want = 1 // the probe that found the level
+			}
+			if n := srv.count("SITE JESOWNER="); n != want {
+				t.Errorf("SITE JESOWNER sent %d times, want %d", n, want)
+			}
+			if n := srv.loginCount(); n != 1 {
+				t.Errorf("logins = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestFTPJESRejectedOwner(t *testing.T) {
+	srv := newFakeFTP(t)
+	srv.noStatLevel = true
+	srv.badOwner = "BAD"
+	srv.lists[""] = "MYJOB    JOB00042 IBMUSER  OUTPUT A        RC=0000\r\n"
+	conn := srv.connection()
+	defer conn.Close()
+
+	if _, err := conn.ListJobs(JobFilter{Owner: "BAD"}); err == nil || !strings.Contains(err.Error(), "not valid") {
+		t.Errorf("ListJobs error = %v, want the rejected owner", err)
+	}
+	// The session keeps the level 2 filters
+	jobs, err := conn.ListJobs(JobFilter{Class: "A"})
+	if err != nil {
+		t.Fatalf("ListJobs by class error: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Errorf("ListJobs by class = %+v, want JOB00042", jobs)
+	}
+	if n := srv.loginCount(); n != 1 {
+		t.Errorf("logins = %d, want 1", n)
+	}
+}
+
+func TestFTPChangePassword(t *testing.T) {
+	srv := newFakeFTP(t)
+	srv.password = "secret"
+	srv.expired = true
+	srv.lists["JOB00042"] = "MYJOB    JOB00042 IBMUSER  ACTIVE A\r\n"
+	conn := srv.connection()
+	defer conn.Close()
+
+	err := conn.Connect()
+	if !errors.Is(err, ErrPasswordExpired) {
+		t.Fatalf("Connect error = %v, want ErrPasswordExpired", err)
+	}
+	if err := conn.ChangePassword("bad/pw"); err == nil {
+		t.Error("expected error for a password containing '/'")
+	}
+	if err := conn.ChangePassword("newpw"); err != nil {
+		t.Fatalf("ChangePassword error: %v", err)
+	}
+	if _, err := conn.GetJobStatus("JOB00042"); err != nil {
+		t.Fatalf("GetJobStatus with the new password error: %v", err)
+	}
+	if srv.count("PASS secret/newpw/newpw") != 1 {
+		t.Error("password not changed with PASS old/new/new")
+	}
+
+	// The old password no longer works
+	if err := srv.connection().Connect(); err == nil || errors.Is(err, ErrPasswordExpired) {
+		t.Errorf("Connect with the old password error = %v, want a plain login failure", err)
+	}
+}
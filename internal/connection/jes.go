@@ -2,53 +2,173 @@ package connection
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/textproto"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// maxJESEntries is the highest JESENTRYLIMIT z/OS FTP accepts.
+const maxJESEntries = 1024
+
+// jesConfig holds what newJESClient needs to log in.
+type jesConfig struct {
+	addr     string
+	user     string
+	password string
+	tlsMode  string
+	tls      *tls.Config
+	data     DataChannel
+}
+
+// jesClient is a hand-rolled FTP session for what jlaffaye/ftp cannot do:
+// the JES interface and raw dataset listings.
 type jesClient struct {
-	conn   net.Conn
-	reader *bufio.Reader
+	conn     net.Conn
+	reader   *bufio.Reader
+	tls      *tls.Config // protects the data channels too when set
+	data     DataChannel
+	noEPSV   bool   // the server rejected EPSV, use PASV
+	fileType string // current SITE FILETYPE, SEQ or JES
+	typ      string // current TYPE, A or I
+	deflate  bool   // MODE Z is on, data transfers are zlib streams
+	user     string
+	level    int // JESINTERFACELEVEL, 0 until known
+
+	// filter is the last filter sent with setFilter, so that polling the
+	// same job does not repeat the SITE commands.
+	filter *JobFilter
 }
 
-func newJESClient(host string, port int, user, password string) (*jesClient, error) {
-	addr := net.JoinHostPort(host, strconv.Itoa(port))
-	conn, err := net.DialTimeout("tcp", addr, ftpTimeout)
+func newJESClient(cfg jesConfig) (*jesClient, error) {
+	conn, err := net.DialTimeout("tcp", cfg.addr, ftpTimeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect: %w", err)
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.addr, err)
+	}
+	if cfg.tlsMode == TLSImplicit {
+		conn = tls.Client(conn, cfg.tls)
 	}
 
 	c := &jesClient{
 		conn:   conn,
 		reader: bufio.NewReader(conn),
+		data:   cfg.data,
+		user:   strings.ToUpper(cfg.user),
 	}
+	if err := c.login(cfg); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
 
+func (c *jesClient) login(cfg jesConfig) error {
 	// Read welcome
 	if _, err := c.readResponse(); err != nil {
-		conn.Close()
-		return nil, err
+		return err
+	}
+
+	if cfg.tlsMode == TLSExplicit {
+		if err := c.cmd("AUTH TLS"); err != nil {
+			return fmt.Errorf("server refused AUTH TLS: %w", err)
+		}
+		tc := tls.Client(c.conn, cfg.tls)
+		tc.SetDeadline(time.Now().Add(ftpTimeout))
+		if err := tc.Handshake(); err != nil {
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		c.conn = tc
+		c.reader = bufio.NewReader(tc)
 	}
 
 	// Login
-	if err := c.cmd("USER %s", user); err != nil {
-		conn.Close()
-		return nil, err
+	if err := c.cmd("USER %s", cfg.user); err != nil {
+		return fmt.Errorf("login failed: %w", err)
 	}
-	if err := c.cmd("PASS %s", password); err != nil {
-		conn.Close()
-		return nil, err
+	if err := c.cmd("PASS %s", cfg.password); err != nil {
+		return loginError(err)
+	}
+
+	if cfg.tlsMode != TLSNone {
+		if err := c.cmd("PBSZ 0"); err != nil {
+			return err
+		}
+		if err := c.cmd("PROT P"); err != nil {
+			return err
+		}
+		c.tls = cfg.tls
+	}
+
+	if cfg.data.Compress {
+		if err := c.enableDeflate(); err != nil {
+			return err
+		}
 	}
 
 	// Enter JES mode
-	if err := c.cmd("SITE FILETYPE=JES"); err != nil {
-		conn.Close()
-		return nil, err
+	if err := c.setFileType("JES"); err != nil {
+		return err
 	}
+	return c.detectLevel()
+}
 
-	return c, nil
+var jesLevelRe = regexp.MustCompile(`JESINTERFACELEVEL\D{0,8}([12])`)
+
+// detectLevel reads the JES interface level from STAT. It decides what LIST
+// shows and which SITE filters exist. When STAT does not tell, level 2 is
+// assumed until the server rejects a filter.
+func (c *jesClient) detectLevel() error {
+	resp, err := c.cmdResp("STAT")
+	if err != nil {
+		if isSessionError(err) {
+			return err
+		}
+		return nil
+	}
+	c.level = parseJESLevel(resp)
+	return nil
+}
+
+// parseJESLevel finds the level in a STAT reply such as
+// "211-JESINTERFACELEVEL is 2", or returns 0.
+func parseJESLevel(stat string) int {
+	m := jesLevelRe.FindStringSubmatch(stat)
+	if m == nil {
+		return 0
+	}
+	level, _ := strconv.Atoi(m[1])
+	return level
+}
+
+// setFileType switches between dataset (SEQ) and JES mode.
+func (c *jesClient) setFileType(fileType string) error {
+	if c.fileType == fileType {
+		return nil
+	}
+	if err := c.cmd("SITE FILETYPE=%s", fileType); err != nil {
+		return err
+	}
+	c.fileType = fileType
+	return nil
+}
+
+// setType sets the transfer type, A (text converted from EBCDIC) or I.
+func (c *jesClient) setType(typ string) error {
+	if c.typ == typ {
+		return nil
+	}
+	if err := c.cmd("TYPE %s", typ); err != nil {
+		return fmt.Errorf("failed to set transfer type %s: %w", typ, err)
+	}
+	c.typ = typ
+	return nil
 }
 
 func (c *jesClient) close() {
@@ -57,26 +177,156 @@ func (c *jesClient) close() {
 }
 
 func (c *jesClient) setOwner(owner string) error {
-	if strings.ContainsAny(owner, "\r\n") {
-		return fmt.Errorf("invalid owner: contains control characters")
+	return c.setFilter(JobFilter{Owner: owner})
+}
+
+// setFilter applies the filters the JES interface supports server-side:
+// owner, job name prefix, status and entry limit.
+func (c *jesClient) setFilter(filter JobFilter) error {
+	if strings.ContainsAny(filter.Owner+filter.Prefix, "\r\n") {
+		return fmt.Errorf("invalid filter: contains control characters")
+	}
+	if err := c.setFileType("JES"); err != nil {
+		return err
+	}
+	if c.filter != nil && sameJESFilter(*c.filter, filter) {
+		return nil
+	}
+	c.filter = nil
+	if c.level == 1 {
+		return c.setFilterLevel1(filter)
+	}
+	if err := c.cmd("SITE JESOWNER=%s", filter.Owner); err != nil {
+		if c.level == 0 && unknownParameter(err) {
+			// JESOWNER is the first thing a level 1 server does not know
+			c.level = 1
+			return c.setFilterLevel1(filter)
+		}
+		return err
+	}
+	if err := c.cmd("SITE JESJOBNAME=%s*", filter.Prefix); err != nil {
+		return err
+	}
+
+	status := filter.Status
+	if status == "" {
+		status = "ALL"
+	}
+	if err := c.cmd("SITE JESSTATUS=%s", status); err != nil {
+		return err
 	}
-	if err := c.cmd("SITE JESOWNER=%s", owner); err != nil {
+
+	if err := c.cmd("SITE JESENTRYLIMIT=%d", jesEntryLimit(filter)); err != nil {
 		return err
 	}
-	return c.cmd("SITE JESJOBNAME=*")
+	c.filter = &filter
+	return nil
+}
+
+// unknownParameter reports whether err is a 500 or 501 reply saying the
+// server does not know a command or SITE parameter, as opposed to one
+// rejecting its value.
+func unknownParameter(err error) bool {
+	msg := strings.ToLower(err.Error())
+	if !strings.HasPrefix(msg, "ftp error: 500") && !strings.HasPrefix(msg, "ftp error: 501") {
+		return false
+	}
+	return strings.Contains(msg, "unknown") || strings.Contains(msg, "unrecognized")
+}
+
+// setFilterLevel1 checks filter against what a level 1 server can do: it
+// only lists the jobs of the logged-on user and has no SITE filters, so
+// prefix, status and limit are left to JobFilter.Apply.
+func (c *jesClient) setFilterLevel1(filter JobFilter) error {
+	if filter.Owner != "" && filter.Owner != "*" && !strings.EqualFold(filter.Owner, c.user) {
+		return fmt.Errorf("listing jobs of %s requires JESINTERFACELEVEL=2 on the server", filter.Owner)
+	}
+	// Level 1 listings show neither class nor return code to filter on
+	if filter.Class != "" {
+		return fmt.Errorf("filtering jobs by class requires JESINTERFACELEVEL=2 on the server")
+	}
+	if filter.RetCode != "" {
+		return fmt.Errorf("filtering jobs by return code requires JESINTERFACELEVEL=2 on the server")
+	}
+	c.filter = &filter
+	return nil
+}
+
+func jesEntryLimit(filter JobFilter) int {
+	if filter.MaxJobs > 0 && filter.MaxJobs < maxJESEntries && filter.serverSideOnly() {
+		return filter.MaxJobs
+	}
+	return maxJESEntries
+}
+
+// sameJESFilter reports whether a and b result in the same SITE commands.
+func sameJESFilter(a, b JobFilter) bool {
+	return a.Owner == b.Owner && a.Prefix == b.Prefix && a.Status == b.Status &&
+		jesEntryLimit(a) == jesEntryLimit(b)
+}
+
+// jobStatus queries a single job by ID instead of listing the whole queue.
+func (c *jesClient) jobStatus(jobid string) (*JobStatus, error) {
+	if strings.ContainsAny(jobid, "\r\n") {
+		return nil, fmt.Errorf("invalid jobid: contains control characters")
+	}
+	if err := c.setOwner("*"); err != nil {
+		return nil, err
+	}
+
+	// Level 1 has no LIST jobid, the job has to be found in the list
+	arg := jobid
+	if c.level == 1 {
+		arg = ""
+	}
+	lines, err := c.retrData("LIST", arg)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range parseJobLines(lines, c.level) {
+		if job.JobID == jobid {
+			return &job, nil
+		}
+	}
+	return nil, fmt.Errorf("job %s not found", jobid)
+}
+
+// spoolFiles lists the DDs of a job from the detail that LIST jobid returns.
+func (c *jesClient) spoolFiles(jobid string) ([]SpoolFile, error) {
+	if strings.ContainsAny(jobid, "\r\n") {
+		return nil, fmt.Errorf("invalid jobid: contains control characters")
+	}
+	if err := c.setOwner("*"); err != nil {
+		return nil, err
+	}
+	if c.level == 1 {
+		return nil, fmt.Errorf("listing spool files requires JESINTERFACELEVEL=2 on the server")
+	}
+
+	lines, err := c.retrData("LIST", jobid)
+	if err != nil {
+		return nil, err
+	}
+	return parseSpoolFileLines(lines), nil
 }
 
 func (c *jesClient) listJobs() ([]JobStatus, error) {
+	if err := c.setFileType("JES"); err != nil {
+		return nil, err
+	}
 	lines, err := c.retrData("LIST", "")
 	if err != nil {
 		return nil, err
 	}
-	return parseJobLines(lines), nil
+	return parseJobLines(lines, c.level), nil
 }
 
 func (c *jesClient) submitJCL(jcl []byte) (string, error) {
-	if err := c.cmd("TYPE A"); err != nil {
-		return "", fmt.Errorf("failed to set ASCII mode: %w", err)
+	if err := c.setFileType("JES"); err != nil {
+		return "", err
+	}
+	if err := c.setType("A"); err != nil {
+		return "", err
 	}
 
 	lines, err := c.storData("STOR SUBMIT", jcl)
@@ -100,63 +350,58 @@ func (c *jesClient) submitJCL(jcl []byte) (string, error) {
 	return "", fmt.Errorf("could not parse job ID from submit response")
 }
 
+// storData uploads data with cmd and returns the completion reply.
 func (c *jesClient) storData(cmd string, data []byte) ([]string, error) {
-	pasvResp, err := c.cmdResp("PASV")
+	dc, err := c.prepareData()
 	if err != nil {
 		return nil, err
 	}
-
-	dataAddr, err := parsePASV(pasvResp)
-	if err != nil {
-		return nil, err
-	}
-
-	dataConn, err := net.DialTimeout("tcp", dataAddr, ftpTimeout)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect data channel: %w", err)
-	}
+	defer dc.close()
 
 	if err := c.send(cmd); err != nil {
-		dataConn.Close()
 		return nil, err
 	}
-
 	resp, err := c.readResponse()
 	if err != nil {
-		dataConn.Close()
 		return nil, err
 	}
 	if !strings.HasPrefix(resp, "125") && !strings.HasPrefix(resp, "150") {
-		dataConn.Close()
 		return nil, fmt.Errorf("STOR failed: %s", resp)
 	}
 
-	dataConn.SetWriteDeadline(time.Now().Add(ftpTimeout))
-	_, err = dataConn.Write(data)
-	dataConn.Close()
+	conn, err := dc.open(c.tls)
+	if err != nil {
+		return nil, err
+	}
+	err = c.writeData(idleConn{conn, ftpTimeout}, data)
+	dc.close()
 	if err != nil {
 		return nil, fmt.Errorf("failed to send data: %w", err)
 	}
 
-	// Read completion response(s)
+	// Read completion response(s); z/OS ends with 250, others with 226
 	var responses []string
 	for {
 		endResp, endErr := c.readResponse()
+		if endErr != nil {
+			return nil, endErr
+		}
 		responses = append(responses, endResp)
-		if endErr != nil || strings.HasPrefix(endResp, "250") {
-			break
+		if strings.HasPrefix(endResp, "2") {
+			return responses, nil
 		}
 	}
-
-	return responses, nil
 }
 
 func (c *jesClient) getJobOutput(jobid string) ([]byte, error) {
 	if strings.ContainsAny(jobid, "\r\n") {
 		return nil, fmt.Errorf("invalid jobid: contains control characters")
 	}
-	if err := c.cmd("TYPE A"); err != nil {
-		return nil, fmt.Errorf("failed to set ASCII mode: %w", err)
+	if err := c.setFileType("JES"); err != nil {
+		return nil, err
+	}
+	if err := c.setType("A"); err != nil {
+		return nil, err
 	}
 	lines, err := c.retrData("RETR", jobid)
 	if err != nil {
@@ -165,31 +410,43 @@ func (c *jesClient) getJobOutput(jobid string) ([]byte, error) {
 	return []byte(strings.Join(lines, "\n")), nil
 }
 
+// retrData runs a download command (RETR, LIST) and returns the lines
+// received. An error reply after some output is ignored, since JES reports
+// spool files it could not send that way.
 func (c *jesClient) retrData(cmd, arg string) ([]string, error) {
-	pasvResp, err := c.cmdResp("PASV")
-	if err != nil {
+	data, err := c.retr(cmd, arg)
+	if err != nil && (data == nil || isSessionError(err)) {
 		return nil, err
 	}
 
-	dataAddr, err := parsePASV(pasvResp)
-	if err != nil {
-		return nil, err
+	lines := make([]string, 0, 256)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err != nil && len(lines) == 0 {
+		return nil, fmt.Errorf("no output available: %w", err)
 	}
+	return lines, nil
+}
 
-	dataConn, err := net.DialTimeout("tcp", dataAddr, ftpTimeout)
+// retr runs a download command and returns the data. When the transfer
+// itself succeeded but the server ends it with an error reply, the data is
+// returned together with that error.
+func (c *jesClient) retr(cmd, arg string) ([]byte, error) {
+	dc, err := c.prepareData()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect data channel: %w", err)
+		return nil, err
 	}
-	defer dataConn.Close()
+	defer dc.close()
 
 	if arg != "" {
-		if err := c.send("%s %s", cmd, arg); err != nil {
-			return nil, fmt.Errorf("failed to send %s: %w", cmd, err)
-		}
+		err = c.send("%s %s", cmd, arg)
 	} else {
-		if err := c.send(cmd); err != nil {
-			return nil, fmt.Errorf("failed to send %s: %w", cmd, err)
-		}
+		err = c.send(cmd)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", cmd, err)
 	}
 
 	resp, err := c.readResponse()
@@ -200,22 +457,20 @@ func (c *jesClient) retrData(cmd, arg string) ([]string, error) {
 		return nil, fmt.Errorf("%s failed: %s", cmd, resp)
 	}
 
-	dataConn.SetReadDeadline(time.Now().Add(ftpTimeout * 2))
-	lines := make([]string, 0, 256)
-	scanner := bufio.NewScanner(dataConn)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	conn, err := dc.open(c.tls)
+	if err != nil {
+		return nil, err
 	}
-	if err := scanner.Err(); err != nil {
+	data, err := c.readData(idleConn{conn, ftpTimeout})
+	dc.close()
+	if err != nil {
 		return nil, fmt.Errorf("failed to read data: %w", err)
 	}
 
-	endResp, endErr := c.readResponse()
-	if endErr != nil && len(lines) == 0 {
-		return nil, fmt.Errorf("no output available: %s", endResp)
+	if _, err := c.readResponse(); err != nil {
+		return data, err
 	}
-
-	return lines, nil
+	return data, nil
 }
 
 func (c *jesClient) cmd(format string, args ...interface{}) error {
@@ -259,6 +514,30 @@ func (c *jesClient) readResponse() (string, error) {
 	return result, nil
 }
 
+// loginError wraps a failed PASS, telling an expired password apart: RACF
+// answers "530 PASS command failed - password has expired".
+func loginError(err error) error {
+	if strings.Contains(err.Error(), "530") && strings.Contains(strings.ToLower(err.Error()), "expired") {
+		return fmt.Errorf("login failed: %w: %v", ErrPasswordExpired, err)
+	}
+	return fmt.Errorf("login failed: %w", err)
+}
+
+// isSessionError reports whether err means the control connection is no
+// longer usable, as opposed to an FTP error reply on a working session.
+func isSessionError(err error) bool {
+	var netErr net.Error
+	if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) || errors.As(err, &netErr) {
+		return true
+	}
+	// 421: service not available, closing control connection
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code == 421 {
+		return true
+	}
+	return strings.Contains(err.Error(), "ftp error: 421")
+}
+
 func parsePASV(resp string) (string, error) {
 	// Parse: 227 Entering Passive Mode (h1,h2,h3,h4,p1,p2)
 	start := strings.Index(resp, "(")
@@ -286,20 +565,89 @@ func parsePASV(resp string) (string, error) {
 	return fmt.Sprintf("%s:%d", host, port), nil
 }
 
-func parseJobLines(lines []string) []JobStatus {
+// parseJobLines parses a LIST reply. At level 2 a LIST jobid reply goes on
+// with the spool files of the job after a line of dashes.
+func parseJobLines(lines []string, level int) []JobStatus {
 	jobs := make([]JobStatus, 0, len(lines))
 	for _, line := range lines {
 		if strings.Contains(line, "JOBNAME") && strings.Contains(line, "JOBID") {
 			continue
 		}
 		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "--------") {
+			break
+		}
 		if line == "" {
 			continue
 		}
-		job := parseJobLine(line)
+
+		var job JobStatus
+		if level == 1 {
+			job = parseJobLineLevel1(line)
+		} else {
+			job = parseJobLine(line)
+		}
 		if job.JobID != "" {
 			jobs = append(jobs, job)
 		}
 	}
 	return jobs
 }
+
+// parseJobLineLevel1 parses the shorter level 1 format, which has no owner,
+// class or return code:
+//
+//	IBMUSERA JOB01234  OUTPUT    3 Spool Files
+func parseJobLineLevel1(line string) JobStatus {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return JobStatus{}
+	}
+	return JobStatus{JobName: fields[0], JobID: fields[1], Status: fields[2]}
+}
+
+// parseSpoolFileLines parses the spool file table of a LIST jobid reply.
+// Columns are taken from the header, since STEPNAME and PROCSTEP may be
+// blank:
+//
+//	ID  STEPNAME PROCSTEP C DDNAME   BYTE-COUNT
+//	001 JES2              A JESMSGLG      1200
+//	004 STEP1    COMPILE  A SYSPRINT       300
+//	007                   H SYSOUT          80
+func parseSpoolFileLines(lines []string) []SpoolFile {
+	var files []SpoolFile
+	var stepCol, procCol, classCol int
+	inTable := false
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "ID" && fields[1] == "STEPNAME" {
+			stepCol = strings.Index(line, "STEPNAME")
+			procCol = strings.Index(line, "PROCSTEP")
+			classCol = strings.Index(line, " C ") + 1
+			inTable = procCol > stepCol && classCol > procCol
+			continue
+		}
+		if !inTable || len(fields) < 4 || len(line) <= classCol {
+			continue
+		}
+
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		rest := strings.Fields(line[classCol:])
+		if len(rest) != 3 {
+			continue
+		}
+		f := SpoolFile{
+			ID:       id,
+			StepName: strings.TrimSpace(line[stepCol:procCol]),
+			ProcStep: strings.TrimSpace(line[procCol:classCol]),
+			Class:    rest[0],
+			DDName:   rest[1],
+		}
+		f.Bytes, _ = strconv.Atoi(rest[2])
+		files = append(files, f)
+	}
+	return files
+}
@@ -0,0 +1,170 @@
+package connection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// listDatasets lists the datasets matching pattern, e.g. IBMUSER.*.
+func (c *jesClient) listDatasets(pattern string) ([]string, error) {
+	if strings.ContainsAny(pattern, "'\r\n") {
+		return nil, fmt.Errorf("invalid pattern: %q", pattern)
+	}
+	if err := c.setFileType("SEQ"); err != nil {
+		return nil, err
+	}
+
+	lines, err := c.retrData("LIST", "'"+pattern+"'")
+	if isEmptyListing(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseDatasetLines(lines), nil
+}
+
+// listMembers lists the members of a PDS, PDSE or load library.
+func (c *jesClient) listMembers(dsn string) ([]Member, error) {
+	if strings.ContainsAny(dsn, "'\r\n") {
+		return nil, fmt.Errorf("invalid dataset name: %q", dsn)
+	}
+	if err := c.setFileType("SEQ"); err != nil {
+		return nil, err
+	}
+
+	// 250 The working directory "IBMUSER.JCL" is a partitioned data set
+	resp, err := c.cmdResp("CWD '%s'", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(resp, "partitioned data set") {
+		return nil, fmt.Errorf("%s is not a partitioned data set", dsn)
+	}
+
+	lines, err := c.retrData("LIST", "")
+	if isEmptyListing(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseMemberLines(lines)
+}
+
+// isEmptyListing reports whether err is the 550 z/OS answers LIST with when
+// nothing matches, e.g. "550 No members found."
+func isEmptyListing(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "ftp error: 550 No ")
+}
+
+// parseDatasetLines returns the names of a dataset-level listing. Migrated
+// datasets and deeper qualifiers (pseudo directories) only have a name:
+//
+//	Volume Unit    Referred Ext Used Recfm Lrecl BlkSz Dsorg Dsname
+//	WRK001 3390   2024/04/16  1   15  FB      80 27920  PO  IBMUSER.JCL
+//	Migrated                                                IBMUSER.OLD.DATA
+//	Pseudo Directory                                        IBMUSER.TEST
+func parseDatasetLines(lines []string) []string {
+	var names []string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] == "Volume" && fields[1] == "Unit" {
+			continue
+		}
+		names = append(names, fields[len(fields)-1])
+	}
+	return names
+}
+
+// parseMemberLines parses the member list of a PDS or PDSE, where members
+// saved without ISPF statistics only have a name:
+//
+//	 Name     VV.MM   Created       Changed      Size  Init   Mod   Id
+//	PROG1     01.00 2024/01/01 2024/01/15 09:00    10    10     0 USER1
+//	NOSTATS
+//
+// or of a load library, where Size is the module length in bytes:
+//
+//	 Name      Size     TTR   Alias-of AC --------- Attributes --------- Amode Rmode
+//	IEFBR14   000008   00000F          00 FO             RN RU            31    ANY
+func parseMemberLines(lines []string) ([]Member, error) {
+	members := make([]Member, 0, len(lines))
+	load := false
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case len(fields) >= 2 && fields[0] == "Volume" && fields[1] == "Unit":
+			return nil, fmt.Errorf("not a member list")
+		case fields[0] == "Name":
+			load = strings.Contains(line, "TTR")
+			continue
+		}
+
+		var m Member
+		switch {
+		case len(fields) == 1:
+			m.Name = fields[0]
+		case load:
+			m = parseLoadModuleLine(fields)
+		default:
+			m = parseMemberLine(line)
+		}
+		if m.Name != "" {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
+
+func parseLoadModuleLine(fields []string) Member {
+	m := Member{Name: fields[0]}
+	if size, err := strconv.ParseInt(fields[1], 16, 64); err == nil {
+		m.Size = int(size)
+	}
+	return m
+}
+
+func parseMemberLine(line string) Member {
+	// Format: Name     VV.MM   Created       Changed      Size  Init   Mod   Id
+	// Example: HSISAPIE  01.82 2024/04/16 2025/12/10 20:18     5    27     0 FALZONE
+	fields := strings.Fields(line)
+	if len(fields) < 8 {
+		return Member{}
+	}
+
+	m := Member{Name: fields[0]}
+
+	// Parse VV.MM
+	if vvmm := strings.Split(fields[1], "."); len(vvmm) == 2 {
+		m.VV, _ = strconv.Atoi(vvmm[0])
+		m.MM, _ = strconv.Atoi(vvmm[1])
+	}
+
+	// Created date
+	m.Created = fields[2]
+
+	// Changed date and time
+	if len(fields) >= 5 {
+		m.Changed = fields[3] + " " + fields[4]
+	}
+
+	// Size, Init, Mod, User
+	if len(fields) >= 6 {
+		m.Size, _ = strconv.Atoi(fields[5])
+	}
+	if len(fields) >= 7 {
+		m.Init, _ = strconv.Atoi(fields[6])
+	}
+	if len(fields) >= 8 {
+		m.Mod, _ = strconv.Atoi(fields[7])
+	}
+	if len(fields) >= 9 {
+		m.User = fields[8]
+	}
+
+	return m
+}
@@ -0,0 +1,217 @@
+package connection
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMemberLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected Member
+	}{
+		{
+			name: "standard line",
+			line: "HSISAPIE  01.82 2024/04/16 2025/12/10 20:18     5    27     0 FALZONE",
+			expected: Member{
+				Name:    "HSISAPIE",
+				VV:      1,
+				MM:      82,
+				Created: "2024/04/16",
+				Changed: "2025/12/10 20:18",
+				Size:    5,
+				Init:    27,
+				Mod:     0,
+				User:    "FALZONE",
+			},
+		},
+		{
+			name: "different version",
+			line: "MYPROG    02.01 2023/01/01 2024/06/15 10:30   100   100    10 USER123",
+			expected: Member{
+				Name:    "MYPROG",
+				VV:      2,
+				MM:      1,
+				Created: "2023/01/01",
+				Changed: "2024/06/15 10:30",
+				Size:    100,
+				Init:    100,
+				Mod:     10,
+				User:    "USER123",
+			},
+		},
+		{
+			name:     "too few fields",
+			line:     "MEMBER 01.00",
+			expected: Member{},
+		},
+		{
+			name:     "empty line",
+			line:     "",
+			expected: Member{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMemberLine(tt.line)
+			if got != tt.expected {
+				t.Errorf("parseMemberLine(%q) = %+v, want %+v", tt.line, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseMemberLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    []Member
+		wantErr bool
+	}{
+		{
+			name: "pds with statistics",
+			lines: []string{
+				" Name     VV.MM   Created       Changed      Size  Init   Mod   Id",
+				"PROG1     01.00 2024/01/01 2024/01/15 09:00    10    10     0 USER1",
+				"PROG2     01.05 2024/02/01 2024/03/15 10:00    20    15     5 USER2",
+			},
+			want: []Member{
+				{Name: "PROG1", VV: 1, MM: 0, Created: "2024/01/01", Changed: "2024/01/15 09:00", Size: 10, Init: 10, User: "USER1"},
+				{Name: "PROG2", VV: 1, MM: 5, Created: "2024/02/01", Changed: "2024/03/15 10:00", Size: 20, Init: 15, Mod: 5, User: "USER2"},
+			},
+		},
+		{
+			name: "pdse with members without statistics",
+			lines: []string{
+				" Name     VV.MM   Created       Changed      Size  Init   Mod   Id",
+				"COPYBOOK",
+				"PROG1     01.00 2024/01/01 2024/01/15 09:00    10    10     0 USER1",
+				"UPLOADED",
+			},
+			want: []Member{
+				{Name: "COPYBOOK"},
+				{Name: "PROG1", VV: 1, Created: "2024/01/01", Changed: "2024/01/15 09:00", Size: 10, Init: 10, User: "USER1"},
+				{Name: "UPLOADED"},
+			},
+		},
+		{
+			name: "load library",
+			lines: []string{
+				" Name      Size     TTR   Alias-of AC --------- Attributes --------- Amode Rmode",
+				"IEFBR14   000008   00000F          00 FO             RN RU            31    ANY",
+				"MYPGM     0012A0   000012          01 FO                              31    ANY",
+				"MYALIAS   0012A0   000012 MYPGM    01 FO                              31    ANY",
+			},
+			want: []Member{
+				{Name: "IEFBR14", Size: 8},
+				{Name: "MYPGM", Size: 4768},
+				{Name: "MYALIAS", Size: 4768},
+			},
+		},
+		{
+			name:  "header only",
+			lines: []string{" Name     VV.MM   Created       Changed      Size  Init   Mod   Id"},
+			want:  []Member{},
+		},
+		{
+			name: "dataset level listing",
+			lines: []string{
+				"Volume Unit    Referred Ext Used Recfm Lrecl BlkSz Dsorg Dsname",
+				"WRK001 3390   2024/04/16  1   15  FB      80 27920  PS  IBMUSER.DATA",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMemberLines(tt.lines)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMemberLines() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMemberLines() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDatasetLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name: "mixed listing",
+			lines: []string{
+				"Volume Unit    Referred Ext Used Recfm Lrecl BlkSz Dsorg Dsname",
+				"WRK001 3390   2024/04/16  1   15  FB      80 27920  PO  IBMUSER.JCL",
+				"WRK002 3390   2025/01/02  1    3  U        0 32760  PO-E  IBMUSER.LOAD",
+				"Migrated                                                IBMUSER.OLD.DATA",
+				"Pseudo Directory                                        IBMUSER.TEST",
+			},
+			want: []string{"IBMUSER.JCL", "IBMUSER.LOAD", "IBMUSER.OLD.DATA", "IBMUSER.TEST"},
+		},
+		{
+			name:  "header only",
+			lines: []string{"Volume Unit    Referred Ext Used Recfm Lrecl BlkSz Dsorg Dsname", ""},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseDatasetLines(tt.lines)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDatasetLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFTPListMembers(t *testing.T) {
+	srv := newFakeFTP(t)
+	srv.lists["IBMUSER.JCL"] = " Name     VV.MM   Created       Changed      Size  Init   Mod   Id\r\n" +
+		"PROG1     01.00 2024/01/01 2024/01/15 09:00    10    10     0 USER1\r\n"
+	srv.lists["'IBMUSER.*'"] = "Volume Unit    Referred Ext Used Recfm Lrecl BlkSz Dsorg Dsname\r\n" +
+		"WRK001 3390   2024/04/16  1   15  FB      80 27920  PO  IBMUSER.JCL\r\n"
+	srv.lists["JOB00042"] = "MYJOB    JOB00042 IBMUSER  OUTPUT A        RC=0000\r\n"
+	conn := srv.connection()
+	defer conn.Close()
+
+	members, err := conn.ListMembers("'IBMUSER.JCL'")
+	if err != nil {
+		t.Fatalf("ListMembers error: %v", err)
+	}
+	if len(members) != 1 || members[0].Name != "PROG1" {
+		t.Errorf("members = %+v", members)
+	}
+
+	datasets, err := conn.ListDatasets("IBMUSER")
+	if err != nil {
+		t.Fatalf("ListDatasets error: %v", err)
+	}
+	if !reflect.DeepEqual(datasets, []string{"IBMUSER.JCL"}) {
+		t.Errorf("datasets = %q", datasets)
+	}
+
+	_, err = conn.ListMembers("IBMUSER.DATA")
+	if err == nil || !strings.Contains(err.Error(), "not a partitioned data set") {
+		t.Errorf("ListMembers of a sequential dataset error = %v", err)
+	}
+
+	// Back to JES mode on the same session
+	if _, err := conn.GetJobStatus("JOB00042"); err != nil {
+		t.Fatalf("GetJobStatus error: %v", err)
+	}
+	if n := srv.count("SITE FILETYPE=JES"); n != 2 {
+		t.Errorf("SITE FILETYPE=JES sent %d times, want 2", n)
+	}
+	if n := srv.loginCount(); n != 1 {
+		t.Errorf("logins = %d, want 1", n)
+	}
+}
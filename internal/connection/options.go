@@ -0,0 +1,42 @@
+package connection
+
+// Option configures a connection created by NewConnection.
+type Option func(*options)
+
+type options struct {
+	tls      TLSOptions
+	data     DataChannel
+	poolSize int
+
+	tokenFile string
+}
+
+// WithTLS sets the TLS options of the connection.
+func WithTLS(t TLSOptions) Option {
+	return func(o *options) {
+		o.tls = t
+	}
+}
+
+// WithDataChannel sets how FTP data connections are opened.
+func WithDataChannel(d DataChannel) Option {
+	return func(o *options) {
+		o.data = d
+	}
+}
+
+// WithPoolSize limits the number of FTP sessions open at once. Zero means
+// the default of 4.
+func WithPoolSize(n int) Option {
+	return func(o *options) {
+		o.poolSize = n
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
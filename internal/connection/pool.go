@@ -0,0 +1,113 @@
+package connection
+
+import (
+	"fmt"
+	"sync"
+)
+
+// defaultPoolSize is the number of FTP sessions a connection opens at most
+// unless the profile sets pool_size.
+const defaultPoolSize = 4
+
+// session is an FTP session a sessionPool can hold.
+type session interface {
+	close()
+}
+
+// sessionPool holds logged-on sessions. Callers take a session with get,
+// which blocks while all slots are busy, and hand it back with put, or with
+// discard when it is broken. Idle sessions are reused last in, first out so
+// that a sequential caller keeps running on one session. Pools of one
+// connection share their slots, so that together they stay within the
+// session limit.
+type sessionPool[S session] struct {
+	open  func() (S, error)
+	slots chan struct{}
+
+	mu     sync.Mutex
+	idle   []S
+	closed bool
+}
+
+// newPoolSlots returns the slots for size sessions at once.
+func newPoolSlots(size int) chan struct{} {
+	if size <= 0 {
+		size = defaultPoolSize
+	}
+	return make(chan struct{}, size)
+}
+
+func newSessionPool[S session](slots chan struct{}, open func() (S, error)) *sessionPool[S] {
+	return &sessionPool[S]{
+		open:  open,
+		slots: slots,
+	}
+}
+
+// get returns an idle session, or logs in a new one if there is none. fresh
+// reports whether the session was just opened.
+func (p *sessionPool[S]) get() (s S, fresh bool, err error) {
+	p.slots <- struct{}{}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.slots
+		return s, false, fmt.Errorf("connection is closed")
+	}
+	if n := len(p.idle); n > 0 {
+		s = p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return s, false, nil
+	}
+	p.mu.Unlock()
+
+	s, err = p.open()
+	if err != nil {
+		<-p.slots
+		return s, false, err
+	}
+	return s, true, nil
+}
+
+// put returns a working session to the pool.
+func (p *sessionPool[S]) put(s S) {
+	p.mu.Lock()
+	if p.closed {
+		s.close()
+	} else {
+		p.idle = append(p.idle, s)
+	}
+	p.mu.Unlock()
+	<-p.slots
+}
+
+// discard closes a broken session and frees its slot.
+func (p *sessionPool[S]) discard(s S) {
+	s.close()
+	<-p.slots
+}
+
+// reopen replaces a broken session by a new one, keeping the slot.
+func (p *sessionPool[S]) reopen(s S) (S, error) {
+	s.close()
+	fresh, err := p.open()
+	if err != nil {
+		<-p.slots
+		return fresh, err
+	}
+	return fresh, nil
+}
+
+// close logs off the idle sessions; busy ones are closed when they are put
+// back.
+func (p *sessionPool[S]) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.idle {
+		s.close()
+	}
+	p.idle = nil
+	p.closed = true
+}
@@ -0,0 +1,41 @@
+package connection
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFTPSessionPool(t *testing.T) {
+	srv := newFakeFTP(t)
+	srv.lists["JOB00042"] = "MYJOB    JOB00042 IBMUSER  OUTPUT A        RC=0000\r\n"
+	srv.files["JOB00042.2"] = "//MYJOB JOB\r\n"
+	conn := srv.connection(WithPoolSize(2))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := conn.GetJobStatus("JOB00042"); err != nil {
+				errs <- err
+			}
+			if _, err := conn.ReadSpoolFile("JOB00042", 2); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent request error: %v", err)
+	}
+	if n := srv.loginCount(); n < 1 || n > 2 {
+		t.Errorf("logins = %d, want at most the pool size 2", n)
+	}
+
+	conn.Close()
+	if _, err := conn.GetJobStatus("JOB00042"); err == nil {
+		t.Error("expected error after Close")
+	}
+}
@@ -0,0 +1,131 @@
+package connection
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// TLS modes of an FTP connection.
+const (
+	TLSNone     = ""
+	TLSExplicit = "explicit" // AUTH TLS on the normal port
+	TLSImplicit = "implicit" // TLS from the first byte, usually port 990
+)
+
+// TLSOptions secure a connection. Without CAFile the system roots are
+// trusted; CertFile and KeyFile are a PEM client certificate, or CertFile
+// alone a PKCS#12 bundle encrypted with CertPassword. Mode only applies to
+// FTP, z/OSMF always uses TLS.
+type TLSOptions struct {
+	Mode         string
+	CAFile       string
+	CertFile     string
+	KeyFile      string
+	CertPassword string
+
+	// ServerName is the name the certificate is checked against, by
+	// default the host.
+	ServerName string
+	// Fingerprint pins the SHA-256 fingerprint of the server certificate,
+	// in hex with or without colons. Without CAFile the pin alone decides.
+	Fingerprint string
+	// Insecure turns certificate verification off.
+	Insecure bool
+}
+
+// config builds the client TLS configuration for host. Data channels reuse
+// the session of the control connection, which z/OS FTP can require.
+func (t TLSOptions) config(host string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         host,
+		MinVersion:         tls.VersionTLS12,
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
+		InsecureSkipVerify: t.Insecure,
+	}
+	if t.ServerName != "" {
+		cfg.ServerName = t.ServerName
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := t.clientCertificate()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if t.Fingerprint != "" {
+		pin, err := parseFingerprint(t.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		if t.CAFile == "" {
+			cfg.InsecureSkipVerify = true
+		}
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("server sent no certificate")
+			}
+			sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+			if string(sum[:]) != string(pin) {
+				return fmt.Errorf("server certificate fingerprint %s does not match the pinned fingerprint", hex.EncodeToString(sum[:]))
+			}
+			return nil
+		}
+	}
+
+	return cfg, nil
+}
+
+func (t TLSOptions) clientCertificate() (tls.Certificate, error) {
+	if t.KeyFile != "" {
+		return tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	}
+
+	data, err := os.ReadFile(t.CertFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, leaf, chain, err := pkcs12.DecodeChain(data, t.CertPassword)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("%s: %w", t.CertFile, err)
+	}
+	cert := tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	return cert, nil
+}
+
+// parseFingerprint decodes a SHA-256 fingerprint such as "AB:CD:..." or
+// "abcd...".
+func parseFingerprint(s string) ([]byte, error) {
+	pin, err := hex.DecodeString(strings.ReplaceAll(s, ":", ""))
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("invalid fingerprint %q: want the SHA-256 of the certificate in hex", s)
+	}
+	return pin, nil
+}
@@ -0,0 +1,278 @@
+package connection
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// testPKI is a throwaway CA with a server certificate for 127.0.0.1 and a
+// client certificate, written as PEM files and as a PKCS#12 bundle.
+type testPKI struct {
+	pool     *x509.CertPool
+	server   tls.Certificate
+	caFile   string
+	certFile string
+	keyFile  string
+	p12File  string
+}
+
+const testP12Password = "changeit"
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "zm test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func(serial int64, tmpl *x509.Certificate) (tls.Certificate, []byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl.SerialNumber = big.NewInt(serial)
+		tmpl.NotBefore = time.Now().Add(-time.Hour)
+		tmpl.NotAfter = time.Now().Add(time.Hour)
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, certPEM, keyPEM
+	}
+
+	server, _, _ := issue(2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	client, clientPEM, clientKey := issue(3, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "IBMUSER"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	p := &testPKI{
+		pool:     x509.NewCertPool(),
+		server:   server,
+		caFile:   filepath.Join(dir, "ca.pem"),
+		certFile: filepath.Join(dir, "client.pem"),
+		keyFile:  filepath.Join(dir, "client.key"),
+		p12File:  filepath.Join(dir, "client.p12"),
+	}
+	p.pool.AddCert(ca)
+	leaf, err := x509.ParseCertificate(client.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p12, err := pkcs12.Modern.Encode(client.PrivateKey, leaf, []*x509.Certificate{ca}, testP12Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, p.p12File, p12)
+	writeFile(t, p.caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))
+	writeFile(t, p.certFile, clientPEM)
+	writeFile(t, p.keyFile, clientKey)
+	return p
+}
+
+// serverConfig returns the server side TLS configuration, requiring a
+// client certificate if clientAuth is set.
+func (p *testPKI) serverConfig(clientAuth bool) *tls.Config {
+	cfg := &tls.Config{Certificates: []tls.Certificate{p.server}}
+	if clientAuth {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = p.pool
+	}
+	return cfg
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFTPS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	for _, mode := range []string{TLSExplicit, TLSImplicit} {
+		t.Run(mode, func(t *testing.T) {
+			srv := newFakeFTPS(t, pki.serverConfig(false), mode == TLSImplicit)
+			srv.files["'IBMUSER.JCL(MYJOB)'"] = "//MYJOB JOB\r\n"
+			srv.lists["JOB00042"] = "MYJOB    JOB00042 IBMUSER  OUTPUT A        RC=0000\r\n"
+
+			conn := srv.connection(WithTLS(TLSOptions{Mode: mode, CAFile: pki.caFile}))
+			if err := conn.Connect(); err != nil {
+				t.Fatalf("Connect error: %v", err)
+			}
+			defer conn.Close()
+
+			src, err := conn.ReadMember("IBMUSER.JCL", "MYJOB")
+			if err != nil {
+				t.Fatalf("ReadMember error: %v", err)
+			}
+			jobid, err := conn.SubmitJCL(src)
+			if err != nil {
+				t.Fatalf("SubmitJCL error: %v", err)
+			}
+			if _, err := conn.GetJobStatus(jobid); err != nil {
+				t.Fatalf("GetJobStatus error: %v", err)
+			}
+
+			if err := conn.WriteMember("IBMUSER.JCL", "COPY", src); err != nil {
+				t.Fatalf("WriteMember error: %v", err)
+			}
+			if got := srv.file("'IBMUSER.JCL(COPY)'"); got != string(src) {
+				t.Errorf("stored %q, want %q", got, src)
+			}
+
+			wantAuth := 2 // transfer session and JES session
+			if mode == TLSImplicit {
+				wantAuth = 0
+			}
+			if n := srv.count("AUTH TLS"); n != wantAuth {
+				t.Errorf("AUTH TLS sent %d times, want %d", n, wantAuth)
+			}
+			if n := srv.count("PROT P"); n != 2 {
+				t.Errorf("PROT P sent %d times, want 2", n)
+			}
+		})
+	}
+}
+
+func TestFTPSUntrustedServer(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := newFakeFTPS(t, pki.serverConfig(false), false)
+
+	conn := srv.connection(WithTLS(TLSOptions{Mode: TLSExplicit}))
+	if err := conn.Connect(); err == nil {
+		conn.Close()
+		t.Fatal("expected certificate verification error")
+	}
+	if _, err := conn.GetJobStatus("JOB00042"); err == nil {
+		t.Fatal("expected certificate verification error for the JES session")
+	}
+	if n := srv.loginCount(); n != 0 {
+		t.Errorf("password sent %d times over an unverified connection", n)
+	}
+}
+
+func TestFTPSClientCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := newFakeFTPS(t, pki.serverConfig(true), true)
+	srv.lists["JOB00042"] = "MYJOB    JOB00042 IBMUSER  OUTPUT A        RC=0000\r\n"
+
+	conn := srv.connection(WithTLS(TLSOptions{
+		Mode:     TLSImplicit,
+		CAFile:   pki.caFile,
+		CertFile: pki.certFile,
+		KeyFile:  pki.keyFile,
+	}))
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.GetJobStatus("JOB00042"); err != nil {
+		t.Fatalf("GetJobStatus error: %v", err)
+	}
+
+	p12 := srv.connection(WithTLS(TLSOptions{
+		Mode:         TLSImplicit,
+		CAFile:       pki.caFile,
+		CertFile:     pki.p12File,
+		CertPassword: testP12Password,
+	}))
+	if err := p12.Connect(); err != nil {
+		t.Fatalf("Connect with PKCS#12 error: %v", err)
+	}
+	p12.Close()
+
+	anon := srv.connection(WithTLS(TLSOptions{Mode: TLSImplicit, CAFile: pki.caFile}))
+	if err := anon.Connect(); err == nil {
+		anon.Close()
+		t.Fatal("expected error without client certificate")
+	}
+}
+
+func TestTLSOptionsConfig(t *testing.T) {
+	pki := newTestPKI(t)
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	writeFile(t, empty, []byte("not a certificate\n"))
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr string
+	}{
+		{"system roots", TLSOptions{Mode: TLSExplicit}, ""},
+		{"ca bundle", TLSOptions{CAFile: pki.caFile}, ""},
+		{"client certificate", TLSOptions{CertFile: pki.certFile, KeyFile: pki.keyFile}, ""},
+		{"missing ca bundle", TLSOptions{CAFile: filepath.Join(t.TempDir(), "nope.pem")}, "failed to read CA bundle"},
+		{"no certificates", TLSOptions{CAFile: empty}, "no certificates found"},
+		{"key mismatch", TLSOptions{CertFile: pki.certFile, KeyFile: pki.caFile}, "failed to load client certificate"},
+		{"pkcs12", TLSOptions{CertFile: pki.p12File, CertPassword: testP12Password}, ""},
+		{"pkcs12 wrong password", TLSOptions{CertFile: pki.p12File, CertPassword: "guess"}, "failed to load client certificate"},
+		{"pem without key", TLSOptions{CertFile: pki.certFile}, "failed to load client certificate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.opts.config("mvs.example.com")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("config() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("config() error: %v", err)
+			}
+			if cfg.ServerName != "mvs.example.com" {
+				t.Errorf("ServerName = %q", cfg.ServerName)
+			}
+		})
+	}
+}
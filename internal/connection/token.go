@@ -0,0 +1,228 @@
+package connection
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tokenCookies are the session cookies z/OSMF returns on authenticate, in
+// order of preference.
+var tokenCookies = []string{"jwtToken", "LtpaToken2"}
+
+// tokenMargin is how long before its expiry a token is no longer used, so
+// that it does not run out in the middle of a command.
+const tokenMargin = time.Minute
+
+// zosmfToken is a z/OSMF session token and, when cached, whom it belongs to.
+type zosmfToken struct {
+	Host    string    `json:"host"`
+	User    string    `json:"user"`
+	Name    string    `json:"name"` // cookie name
+	Value   string    `json:"value"`
+	Expires time.Time `json:"expires"` // zero if the server did not say
+}
+
+func (t *zosmfToken) valid(now time.Time) bool {
+	return t.Value != "" && (t.Expires.IsZero() || now.Add(tokenMargin).Before(t.Expires))
+}
+
+// WithTokenCache keeps the z/OSMF session token in the file at path, so that
+// later commands do not log in again while it is valid.
+func WithTokenCache(path string) Option {
+	return func(o *options) {
+		o.tokenFile = path
+	}
+}
+
+// DefaultTokenPath returns ~/.zm/tokens/<profile>.json.
+func DefaultTokenPath(profile string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find home directory: %w", err)
+	}
+	return filepath.Join(home, ".zm", "tokens", url.PathEscape(profile)+".json"), nil
+}
+
+// sessionToken returns the token to send, logging in if there is none. It
+// returns nil when the server has no token support and every request has to
+// carry the credentials.
+func (z *ZOSMFConnection) sessionToken() (*zosmfToken, error) {
+	z.tokenMu.Lock()
+	defer z.tokenMu.Unlock()
+
+	if z.noToken {
+		return nil, nil
+	}
+	if !z.tokenLoaded && z.opts.tokenFile != "" {
+		z.token = z.loadToken()
+		z.tokenLoaded = true
+	}
+	if z.token != nil && z.token.valid(time.Now()) {
+		return z.token, nil
+	}
+
+	t, err := z.authenticate()
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		z.noToken = true
+		return nil, nil
+	}
+	z.token = t
+	z.saveToken(t)
+	return t, nil
+}
+
+// dropToken forgets a token the server rejected, unless another request
+// already replaced it.
+func (z *ZOSMFConnection) dropToken(t *zosmfToken) {
+	z.tokenMu.Lock()
+	defer z.tokenMu.Unlock()
+	if z.token == t {
+		z.token = nil
+	}
+}
+
+// authenticate logs in with /zosmf/services/authenticate. Releases without
+// the service answer 404, which means no token.
+func (z *ZOSMFConnection) authenticate() (*zosmfToken, error) {
+	req, err := http.NewRequest("POST", z.baseURL+"/zosmf/services/authenticate", nil)
+	if err != nil {
+		return nil, err
+	}
+	z.setCredentials(req)
+	req.Header.Set("X-CSRF-ZOSMF-HEADER", "*")
+
+	resp, err := z.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		resp.Body.Close()
+		return nil, nil
+	default:
+		return nil, zosmfError("failed to log in", resp)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	cookies := resp.Cookies()
+	for _, name := range tokenCookies {
+		for _, c := range cookies {
+			if c.Name != name || c.Value == "" {
+				continue
+			}
+			t := &zosmfToken{Host: z.host, User: z.user, Name: c.Name, Value: c.Value}
+			switch {
+			case c.MaxAge > 0:
+				t.Expires = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+			case !c.Expires.IsZero():
+				t.Expires = c.Expires
+			case c.Name == "jwtToken":
+				t.Expires = jwtExpiry(c.Value)
+			}
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// ChangePassword uses the password change of the authenticate service,
+// which also takes an expired password. The next request logs in with the
+// new one.
+func (z *ZOSMFConnection) ChangePassword(newPassword string) error {
+	if newPassword == "" {
+		return fmt.Errorf("the new password must not be empty")
+	}
+	body, err := json.Marshal(map[string]string{
+		"userID": z.user,
+		"oldPwd": z.password,
+		"newPwd": newPassword,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", z.baseURL+"/zosmf/services/authenticate", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-ZOSMF-HEADER", "*")
+
+	resp, err := z.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", certHint(err))
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return zosmfError("failed to change password", resp)
+	}
+	resp.Body.Close()
+
+	z.tokenMu.Lock()
+	z.password = newPassword
+	z.token = nil
+	z.tokenLoaded = true // the cached token is from the old password
+	z.noToken = false
+	z.tokenMu.Unlock()
+	return nil
+}
+
+// jwtExpiry reads the exp claim of a JWT, or returns the zero time.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
+
+// loadToken reads the cached token, ignoring a missing or foreign one.
+func (z *ZOSMFConnection) loadToken() *zosmfToken {
+	data, err := os.ReadFile(z.opts.tokenFile)
+	if err != nil {
+		return nil
+	}
+	var t zosmfToken
+	if json.Unmarshal(data, &t) != nil || t.Host != z.host || !strings.EqualFold(t.User, z.user) {
+		return nil
+	}
+	return &t
+}
+
+// saveToken caches t. The cache only saves logins, so failing to write it
+// is not an error.
+func (z *ZOSMFConnection) saveToken(t *zosmfToken) {
+	if z.opts.tokenFile == "" {
+		return
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(z.opts.tokenFile), 0700); err != nil {
+		return
+	}
+	os.WriteFile(z.opts.tokenFile, data, 0600)
+}
@@ -0,0 +1,241 @@
+package connection
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer is a z/OSMF stand-in handing out numbered tokens. Requests
+// need the current token, or basic auth if tokens are disabled.
+type tokenServer struct {
+	mu       sync.Mutex
+	disabled bool   // answer authenticate with 404
+	password string // "pass" if empty
+	expired  bool
+	current  string
+	logins   int
+	basic    int
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	password := s.password
+	if password == "" {
+		password = "pass"
+	}
+	if r.URL.Path == "/zosmf/services/authenticate" && r.Method == "PUT" {
+		var change struct {
+			UserID string `json:"userID"`
+			OldPwd string `json:"oldPwd"`
+			NewPwd string `json:"newPwd"`
+		}
+		json.NewDecoder(r.Body).Decode(&change)
+		if change.UserID != "user" || change.OldPwd != password {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"returnCode":4,"reasonCode":1,"message":"The user ID or password is not valid."}`)
+			return
+		}
+		s.password, s.expired = change.NewPwd, false
+		return
+	}
+	if r.URL.Path == "/zosmf/services/authenticate" {
+		if s.disabled {
+			http.NotFound(w, r)
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if s.expired {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"returnCode":4,"reasonCode":2,"message":"The password has expired."}`)
+			return
+		}
+		s.logins++
+		s.current = fmt.Sprintf("token%d", s.logins)
+		http.SetCookie(w, &http.Cookie{Name: "LtpaToken2", Value: s.current})
+		return
+	}
+
+	if c, err := r.Cookie("LtpaToken2"); err == nil && c.Value == s.current {
+		fmt.Fprint(w, `{"items":[]}`)
+		return
+	}
+	if s.disabled {
+		if _, _, ok := r.BasicAuth(); ok {
+			if s.expired {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"returnCode":4,"reasonCode":2,"message":"The password has expired."}`)
+				return
+			}
+			s.basic++
+			fmt.Fprint(w, `{"items":[]}`)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func (s *tokenServer) revoke() {
+	s.mu.Lock()
+	s.current = "revoked"
+	s.mu.Unlock()
+}
+
+func TestZOSMFTokenSession(t *testing.T) {
+	ts := &tokenServer{}
+	srv := httptest.NewTLSServer(ts)
+	defer srv.Close()
+	ca := testServerCA(t, srv)
+	cache := filepath.Join(t.TempDir(), "tokens", "default.json")
+
+	open := func() *ZOSMFConnection {
+		conn := testZOSMFConnection(t, srv, TLSOptions{CAFile: ca})
+		conn.opts.tokenFile = cache
+		if err := conn.Connect(); err != nil {
+			t.Fatalf("Connect error: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	conn := open()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := conn.ListDatasets("USER"); err != nil {
+				t.Errorf("ListDatasets error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if ts.logins != 1 {
+		t.Errorf("logins = %d, want 1", ts.logins)
+	}
+
+	// A second command reuses the cached token
+	if _, err := open().ListDatasets("USER"); err != nil {
+		t.Fatalf("ListDatasets with cached token error: %v", err)
+	}
+	if ts.logins != 1 {
+		t.Errorf("logins after reuse = %d, want 1", ts.logins)
+	}
+	if fi, err := os.Stat(cache); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("token cache = %v, %v, want mode 0600", fi, err)
+	}
+
+	// A rejected token is replaced transparently
+	ts.revoke()
+	if _, err := conn.ListDatasets("USER"); err != nil {
+		t.Fatalf("ListDatasets after revoke error: %v", err)
+	}
+	if ts.logins != 2 {
+		t.Errorf("logins after revoke = %d, want 2", ts.logins)
+	}
+
+	// Wrong credentials fail on login
+	bad := testZOSMFConnection(t, srv, TLSOptions{CAFile: ca})
+	bad.password = "wrong"
+	bad.Connect()
+	if _, err := bad.ListDatasets("USER"); err == nil {
+		t.Error("expected login error with a wrong password")
+	}
+}
+
+func TestZOSMFWithoutTokens(t *testing.T) {
+	ts := &tokenServer{disabled: true}
+	srv := httptest.NewTLSServer(ts)
+	defer srv.Close()
+
+	conn := testZOSMFConnection(t, srv, TLSOptions{CAFile: testServerCA(t, srv)})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	defer conn.Close()
+	for i := 0; i < 2; i++ {
+		if _, err := conn.ListDatasets("USER"); err != nil {
+			t.Fatalf("ListDatasets error: %v", err)
+		}
+	}
+	if ts.basic != 2 {
+		t.Errorf("requests with basic auth = %d, want 2", ts.basic)
+	}
+}
+
+func TestZOSMFExpiredWithoutTokens(t *testing.T) {
+	ts := &tokenServer{disabled: true, expired: true}
+	srv := httptest.NewTLSServer(ts)
+	defer srv.Close()
+
+	conn := testZOSMFConnection(t, srv, TLSOptions{CAFile: testServerCA(t, srv)})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ListDatasets("USER"); !errors.Is(err, ErrPasswordExpired) {
+		t.Fatalf("ListDatasets error = %v, want ErrPasswordExpired", err)
+	}
+}
+
+func TestZOSMFChangePassword(t *testing.T) {
+	ts := &tokenServer{expired: true}
+	srv := httptest.NewTLSServer(ts)
+	defer srv.Close()
+
+	conn := testZOSMFConnection(t, srv, TLSOptions{CAFile: testServerCA(t, srv)})
+	conn.opts.tokenFile = filepath.Join(t.TempDir(), "default.json")
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ListDatasets("USER"); !errors.Is(err, ErrPasswordExpired) {
+		t.Fatalf("ListDatasets error = %v, want ErrPasswordExpired", err)
+	}
+	if err := conn.ChangePassword("newpass"); err != nil {
+		t.Fatalf("ChangePassword error: %v", err)
+	}
+	if _, err := conn.ListDatasets("USER"); err != nil {
+		t.Fatalf("ListDatasets with the new password error: %v", err)
+	}
+
+	if err := conn.ChangePassword("other"); err != nil {
+		t.Fatalf("second ChangePassword error: %v", err)
+	}
+	conn.password = "wrong"
+	if err := conn.ChangePassword("again"); err == nil || !strings.Contains(err.Error(), "not valid") {
+		t.Errorf("ChangePassword with a wrong password error = %v", err)
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"USER","exp":1760000000}`))
+	tests := []struct {
+		token string
+		want  time.Time
+	}{
+		{"eyJhbGciOiJSUzI1NiJ9." + claims + ".c2ln", time.Unix(1760000000, 0)},
+		{"not-a-jwt", time.Time{}},
+		{"a.!!!.c", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := jwtExpiry(tt.token); !got.Equal(tt.want) {
+			t.Errorf("jwtExpiry(%q) = %v, want %v", tt.token, got, tt.want)
+		}
+	}
+}
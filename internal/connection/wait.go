@@ -0,0 +1,64 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	initialPollInterval = 1 * time.Second
+	maxPollInterval     = 30 * time.Second
+)
+
+// WaitForJob polls the job status until the job reaches OUTPUT or ctx is done.
+// The poll interval starts at one second and doubles up to a 30 second cap.
+// progress, if not nil, is called after every poll that did not complete the job.
+func WaitForJob(ctx context.Context, conn Connection, jobid string, progress func(*JobStatus)) (*JobStatus, error) {
+	interval := initialPollInterval
+	var last *JobStatus
+
+	for {
+		status, err := conn.GetJobStatus(jobid)
+		if err != nil {
+			return nil, err
+		}
+		last = status
+
+		if status.Status == "OUTPUT" {
+			return status, nil
+		}
+		if progress != nil {
+			progress(status)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, waitError(ctx, jobid, last)
+		case <-timer.C:
+		}
+
+		interval = nextPollInterval(interval)
+	}
+}
+
+func nextPollInterval(d time.Duration) time.Duration {
+	d *= 2
+	if d > maxPollInterval {
+		return maxPollInterval
+	}
+	return d
+}
+
+func waitError(ctx context.Context, jobid string, last *JobStatus) error {
+	state := "unknown"
+	if last != nil && last.Status != "" {
+		state = last.Status
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out waiting for job %s (last status: %s): %w", jobid, state, ctx.Err())
+	}
+	return fmt.Errorf("stopped waiting for job %s (last status: %s): %w", jobid, state, ctx.Err())
+}
@@ -0,0 +1,91 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type statusStub struct {
+	Connection
+	statuses []string
+	calls    int
+}
+
+func (s *statusStub) GetJobStatus(jobid string) (*JobStatus, error) {
+	st := s.statuses[len(s.statuses)-1]
+	if s.calls < len(s.statuses) {
+		st = s.statuses[s.calls]
+	}
+	s.calls++
+	return &JobStatus{JobID: jobid, Status: st, RetCode: "CC 0000"}, nil
+}
+
+func TestNextPollInterval(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want time.Duration
+	}{
+		{1 * time.Second, 2 * time.Second},
+		{8 * time.Second, 16 * time.Second},
+		{16 * time.Second, maxPollInterval},
+		{maxPollInterval, maxPollInterval},
+	}
+
+	for _, tt := range tests {
+		if got := nextPollInterval(tt.in); got != tt.want {
+			t.Errorf("nextPollInterval(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWaitForJobCompleted(t *testing.T) {
+	stub := &statusStub{statuses: []string{"OUTPUT"}}
+
+	status, err := WaitForJob(context.Background(), stub, "JOB00001", nil)
+	if err != nil {
+		t.Fatalf("WaitForJob error: %v", err)
+	}
+	if status.Status != "OUTPUT" {
+		t.Errorf("Status = %q, want OUTPUT", status.Status)
+	}
+	if stub.calls != 1 {
+		t.Errorf("calls = %d, want 1", stub.calls)
+	}
+}
+
+func TestWaitForJobTimeout(t *testing.T) {
+	stub := &statusStub{statuses: []string{"INPUT"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	polls := 0
+	_, err := WaitForJob(ctx, stub, "JOB00001", func(*JobStatus) { polls++ })
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "INPUT") {
+		t.Errorf("error = %q, want timeout with last status", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %q, want it to wrap context.DeadlineExceeded", err)
+	}
+	if polls != 1 {
+		t.Errorf("progress calls = %d, want 1", polls)
+	}
+}
+
+func TestWaitForJobCanceled(t *testing.T) {
+	stub := &statusStub{statuses: []string{"ACTIVE"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := WaitForJob(ctx, stub, "JOB00001", nil)
+	if err == nil || !strings.Contains(err.Error(), "stopped waiting") || !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want cancellation error", err)
+	}
+}
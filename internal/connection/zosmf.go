@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,26 +21,37 @@ type ZOSMFConnection struct {
 	port      int
 	user      string
 	password  string
+	opts      options
 	client    *http.Client
 	transport *http.Transport
 	baseURL   string
+
+	// token is the session token requests are sent with, see token.go.
+	tokenMu     sync.Mutex
+	token       *zosmfToken
+	tokenLoaded bool // the cache was read
+	noToken     bool // the server has no token login, send credentials
 }
 
-func NewZOSMFConnection(host string, port int, user, password string) *ZOSMFConnection {
+func NewZOSMFConnection(host string, port int, user, password string, opts ...Option) *ZOSMFConnection {
 	return &ZOSMFConnection{
 		host:     host,
 		port:     port,
 		user:     user,
 		password: password,
+		opts:     newOptions(opts),
 	}
 }
 
 func (z *ZOSMFConnection) Connect() error {
+	tlsConfig, err := z.opts.tls.config(z.host)
+	if err != nil {
+		return err
+	}
+
 	z.baseURL = fmt.Sprintf("https://%s:%d", z.host, z.port)
 	z.transport = &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
+		TLSClientConfig:     tlsConfig,
 		MaxIdleConns:        10,
 		IdleConnTimeout:     90 * time.Second,
 		TLSHandshakeTimeout: 10 * time.Second,
@@ -62,23 +75,86 @@ func (z *ZOSMFConnection) Close() error {
 	return nil
 }
 
+// doRequest sends a request with the session token, logging in first if
+// needed. A request rejected with 401 is sent once more after a new login,
+// in case the token expired or was revoked.
 func (z *ZOSMFConnection) doRequest(method, path string, body io.Reader, extraHeaders ...string) (*http.Response, error) {
 	req, err := http.NewRequest(method, z.baseURL+path, body)
 	if err != nil {
 		return nil, err
 	}
-
-	req.SetBasicAuth(z.user, z.password)
 	req.Header.Set("X-CSRF-ZOSMF-HEADER", "*")
-
 	for i := 0; i+1 < len(extraHeaders); i += 2 {
 		req.Header.Set(extraHeaders[i], extraHeaders[i+1])
 	}
 
-	return z.client.Do(req)
+	token, err := z.sessionToken()
+	if err != nil {
+		return nil, certHint(err)
+	}
+	resp, err := z.send(req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || token == nil {
+		return resp, err
+	}
+	if body != nil && req.GetBody == nil {
+		return resp, nil // the body cannot be sent again
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	z.dropToken(token)
+	if token, err = z.sessionToken(); err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return z.send(retry, token)
+}
+
+func (z *ZOSMFConnection) send(req *http.Request, token *zosmfToken) (*http.Response, error) {
+	if token != nil {
+		req.Header.Del("Cookie")
+		req.AddCookie(&http.Cookie{Name: token.Name, Value: token.Value})
+	} else {
+		z.setCredentials(req)
+	}
+	resp, err := z.client.Do(req)
+	return resp, certHint(err)
 }
 
+// setCredentials adds basic auth, unless the client certificate logs in.
+func (z *ZOSMFConnection) setCredentials(req *http.Request) {
+	if z.opts.tls.CertFile == "" {
+		req.SetBasicAuth(z.user, z.password)
+	}
+}
+
+// certHint points at the profile settings when the server certificate is
+// not trusted.
+func certHint(err error) error {
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return fmt.Errorf("%w (trust the server with ca_file or fingerprint in the tls settings of the profile)", err)
+	}
+	return err
+}
+
+// zosmfError describes a failed request. A 401 that gives an expired
+// password as the reason is ErrPasswordExpired, whether it answered the
+// login or a request carrying the credentials.
 func zosmfError(action string, resp *http.Response) error {
+	err := replyError(action, resp)
+	if resp.StatusCode == http.StatusUnauthorized && strings.Contains(strings.ToLower(err.Error()), "expired") {
+		return fmt.Errorf("%w: %v", ErrPasswordExpired, err)
+	}
+	return err
+}
+
+func replyError(action string, resp *http.Response) error {
 	defer resp.Body.Close()
 	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
 
@@ -257,6 +333,29 @@ func (z *ZOSMFConnection) SubmitJCL(jcl []byte) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to submit JCL: %w", err)
 	}
+	return parseSubmitResponse(resp)
+}
+
+// SubmitMember asks z/OSMF to submit the JCL straight from the dataset,
+// avoiding a download and re-upload of the member.
+func (z *ZOSMFConnection) SubmitMember(dataset, member string) (string, error) {
+	dsn := strings.Trim(dataset, "'")
+	body, err := json.Marshal(map[string]string{
+		"file": fmt.Sprintf("//'%s(%s)'", dsn, member),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := z.doRequest("PUT", "/zosmf/restjobs/jobs", bytes.NewReader(body),
+		"Content-Type", "application/json")
+	if err != nil {
+		return "", fmt.Errorf("failed to submit %s(%s): %w", dsn, member, err)
+	}
+	return parseSubmitResponse(resp)
+}
+
+func parseSubmitResponse(resp *http.Response) (string, error) {
 	if resp.StatusCode != http.StatusCreated {
 		return "", zosmfError("failed to submit JCL", resp)
 	}
@@ -274,19 +373,40 @@ func (z *ZOSMFConnection) SubmitJCL(jcl []byte) (string, error) {
 }
 
 type jobsListResponse struct {
-	JobID   string `json:"jobid"`
-	JobName string `json:"jobname"`
-	Owner   string `json:"owner"`
-	Status  string `json:"status"`
-	RetCode string `json:"retcode"`
-	Class   string `json:"class"`
+	JobID         string `json:"jobid"`
+	JobName       string `json:"jobname"`
+	Owner         string `json:"owner"`
+	Status        string `json:"status"`
+	RetCode       string `json:"retcode"`
+	Class         string `json:"class"`
+	ExecSubmitted string `json:"exec-submitted"`
+	ExecStarted   string `json:"exec-started"`
+	ExecEnded     string `json:"exec-ended"`
 }
 
-func (z *ZOSMFConnection) ListJobs(owner string) ([]JobStatus, error) {
-	if owner == "" {
-		owner = z.user
+func (z *ZOSMFConnection) ListJobs(filter JobFilter) ([]JobStatus, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("owner", filter.Owner)
+	if filter.Owner == "" {
+		query.Set("owner", z.user)
+	}
+	query.Set("prefix", filter.Prefix+"*")
+	if filter.Status == "ACTIVE" {
+		query.Set("status", "active")
+	}
+	// Other statuses are filtered here, after the server applied the limit
+	if filter.MaxJobs > 0 && filter.serverSideOnly() && (filter.Status == "" || filter.Status == "ACTIVE") {
+		query.Set("max-jobs", strconv.Itoa(filter.MaxJobs))
 	}
-	path := "/zosmf/restjobs/jobs?owner=" + url.QueryEscape(owner) + "&prefix=*"
+	if !filter.Since.IsZero() {
+		query.Set("exec-data", "Y")
+	}
+
+	path := "/zosmf/restjobs/jobs?" + query.Encode()
 	resp, err := z.doRequest("GET", path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list jobs: %w", err)
@@ -301,26 +421,41 @@ func (z *ZOSMFConnection) ListJobs(owner string) ([]JobStatus, error) {
 		return nil, fmt.Errorf("failed to parse jobs list: %w", err)
 	}
 
-	return parseZOSMFJobs(items), nil
+	return filter.Apply(parseZOSMFJobs(items)), nil
 }
 
 func parseZOSMFJobs(items []jobsListResponse) []JobStatus {
 	jobs := make([]JobStatus, 0, len(items))
 	for _, item := range items {
 		jobs = append(jobs, JobStatus{
-			JobID:   item.JobID,
-			JobName: item.JobName,
-			Owner:   item.Owner,
-			Status:  item.Status,
-			RetCode: item.RetCode,
-			Class:   item.Class,
+			JobID:     item.JobID,
+			JobName:   item.JobName,
+			Owner:     item.Owner,
+			Status:    item.Status,
+			RetCode:   item.RetCode,
+			Class:     item.Class,
+			Submitted: parseZOSMFTime(item.ExecSubmitted),
+			Started:   parseZOSMFTime(item.ExecStarted),
+			Ended:     parseZOSMFTime(item.ExecEnded),
 		})
 	}
 	return jobs
 }
 
+// parseZOSMFTime parses exec-data timestamps like "2024-06-14T10:52:33.120Z".
+func parseZOSMFTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 func (z *ZOSMFConnection) GetJobStatus(jobid string) (*JobStatus, error) {
-	path := "/zosmf/restjobs/jobs?owner=*&jobid=" + url.QueryEscape(jobid)
+	path := "/zosmf/restjobs/jobs?owner=*&exec-data=Y&jobid=" + url.QueryEscape(jobid)
 	resp, err := z.doRequest("GET", path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get job status: %w", err)
@@ -339,30 +474,30 @@ func (z *ZOSMFConnection) GetJobStatus(jobid string) (*JobStatus, error) {
 		return nil, fmt.Errorf("job %s not found", jobid)
 	}
 
-	job := &JobStatus{
-		JobID:   items[0].JobID,
-		JobName: items[0].JobName,
-		Owner:   items[0].Owner,
-		Status:  items[0].Status,
-		RetCode: items[0].RetCode,
-		Class:   items[0].Class,
-	}
-	return job, nil
+	job := parseZOSMFJobs(items[:1])[0]
+	return &job, nil
 }
 
 type jobFileResponse struct {
-	ID       int    `json:"id"`
-	DDName   string `json:"ddname"`
-	StepName string `json:"stepname"`
+	ID          int    `json:"id"`
+	DDName      string `json:"ddname"`
+	StepName    string `json:"stepname"`
+	ProcStep    string `json:"procstep"`
+	Class       string `json:"class"`
+	ByteCount   int    `json:"byte-count"`
+	RecordCount int    `json:"record-count"`
 }
 
-func (z *ZOSMFConnection) GetJobOutput(jobid string) ([]byte, error) {
+func (z *ZOSMFConnection) ListSpoolFiles(jobid string) ([]SpoolFile, error) {
 	status, err := z.GetJobStatus(jobid)
 	if err != nil {
 		return nil, err
 	}
+	return z.listSpoolFiles(status.JobName, jobid)
+}
 
-	filesPath := fmt.Sprintf("/zosmf/restjobs/jobs/%s/%s/files", status.JobName, jobid)
+func (z *ZOSMFConnection) listSpoolFiles(jobname, jobid string) ([]SpoolFile, error) {
+	filesPath := fmt.Sprintf("/zosmf/restjobs/jobs/%s/%s/files", jobname, jobid)
 	resp, err := z.doRequest("GET", filesPath, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list job files: %w", err)
@@ -372,11 +507,79 @@ func (z *ZOSMFConnection) GetJobOutput(jobid string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 
-	var files []jobFileResponse
-	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+	var items []jobFileResponse
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
 		return nil, fmt.Errorf("failed to parse job files: %w", err)
 	}
 
+	files := make([]SpoolFile, 0, len(items))
+	for _, item := range items {
+		files = append(files, SpoolFile{
+			ID:       item.ID,
+			DDName:   item.DDName,
+			StepName: item.StepName,
+			ProcStep: item.ProcStep,
+			Class:    item.Class,
+			Bytes:    item.ByteCount,
+			Records:  item.RecordCount,
+		})
+	}
+	return files, nil
+}
+
+func (z *ZOSMFConnection) ReadSpoolFile(jobid string, id int) ([]byte, error) {
+	status, err := z.GetJobStatus(jobid)
+	if err != nil {
+		return nil, err
+	}
+	return z.readSpoolFile(status.JobName, jobid, id)
+}
+
+func (z *ZOSMFConnection) readSpoolFile(jobname, jobid string, id int) ([]byte, error) {
+	recordsPath := fmt.Sprintf("/zosmf/restjobs/jobs/%s/%s/files/%d/records", jobname, jobid, id)
+	resp, err := z.doRequest("GET", recordsPath, nil, "X-IBM-Data-Type", "text")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, zosmfError(fmt.Sprintf("failed to read spool file %d", id), resp)
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// GetJobJCL returns the JCL of a job as it was submitted.
+func (z *ZOSMFConnection) GetJobJCL(jobid string) ([]byte, error) {
+	status, err := z.GetJobStatus(jobid)
+	if err != nil {
+		return nil, err
+	}
+
+	jclPath := fmt.Sprintf("/zosmf/restjobs/jobs/%s/%s/files/JCL/records", status.JobName, jobid)
+	resp, err := z.doRequest("GET", jclPath, nil, "X-IBM-Data-Type", "text")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, zosmfError(fmt.Sprintf("failed to get JCL of %s", jobid), resp)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (z *ZOSMFConnection) GetJobOutput(jobid string) ([]byte, error) {
+	status, err := z.GetJobStatus(jobid)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := z.listSpoolFiles(status.JobName, jobid)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(files) == 0 {
 		return nil, nil
 	}
@@ -395,17 +598,9 @@ func (z *ZOSMFConnection) GetJobOutput(jobid string) ([]byte, error) {
 
 	for i, file := range files {
 		wg.Add(1)
-		go func(idx int, f jobFileResponse) {
+		go func(idx int, f SpoolFile) {
 			defer wg.Done()
-			recordsPath := fmt.Sprintf("/zosmf/restjobs/jobs/%s/%s/files/%d/records",
-				status.JobName, jobid, f.ID)
-			recResp, err := z.doRequest("GET", recordsPath, nil, "X-IBM-Data-Type", "text")
-			if err != nil {
-				results[idx] = spoolResult{index: idx, err: fmt.Errorf("failed to read DD %s: %w", f.DDName, err)}
-				return
-			}
-			body, err := io.ReadAll(recResp.Body)
-			recResp.Body.Close()
+			body, err := z.readSpoolFile(status.JobName, jobid, f.ID)
 			if err != nil {
 				results[idx] = spoolResult{index: idx, err: fmt.Errorf("failed to read DD %s: %w", f.DDName, err)}
 				return
@@ -1,6 +1,20 @@
 package connection
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -88,3 +102,325 @@ func TestNewConnection(t *testing.T) {
 		})
 	}
 }
+
+// newTestZOSMF connects a ZOSMFConnection to a local TLS test server,
+// trusting its certificate.
+func newTestZOSMF(t *testing.T, handler http.HandlerFunc) *ZOSMFConnection {
+	t.Helper()
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+
+	conn := testZOSMFConnection(t, srv, TLSOptions{CAFile: testServerCA(t, srv)})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func testZOSMFConnection(t *testing.T, srv *httptest.Server, opts TLSOptions) *ZOSMFConnection {
+	t.Helper()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parse server URL: %v", err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	return NewZOSMFConnection(u.Hostname(), port, "user", "pass", WithTLS(opts))
+}
+
+// testServerCA writes the certificate of a test server to a PEM file.
+func testServerCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	return path
+}
+
+func TestZOSMFTLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items":[]}`)
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // rejected handshakes
+	srv.StartTLS()
+	defer srv.Close()
+
+	ca := testServerCA(t, srv)
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	pin := hex.EncodeToString(sum[:])
+	other := strings.Repeat("ab", sha256.Size)
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr string
+	}{
+		{"system roots", TLSOptions{}, "certificate"},
+		{"ca bundle", TLSOptions{CAFile: ca}, ""},
+		{"server name", TLSOptions{CAFile: ca, ServerName: "example.com"}, ""},
+		{"wrong server name", TLSOptions{CAFile: ca, ServerName: "mvs.example.org"}, "certificate"},
+		{"fingerprint", TLSOptions{Fingerprint: pin}, ""},
+		{"fingerprint with colons", TLSOptions{Fingerprint: strings.ToUpper(colonHex(sum[:]))}, ""},
+		{"fingerprint and ca bundle", TLSOptions{CAFile: ca, Fingerprint: pin}, ""},
+		{"wrong fingerprint", TLSOptions{Fingerprint: other}, "does not match the pinned fingerprint"},
+		{"insecure", TLSOptions{Insecure: true}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := testZOSMFConnection(t, srv, tt.opts)
+			if err := conn.Connect(); err != nil {
+				t.Fatalf("Connect error: %v", err)
+			}
+			defer conn.Close()
+
+			_, err := conn.ListDatasets("USER")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ListDatasets error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ListDatasets error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	conn := testZOSMFConnection(t, srv, TLSOptions{Fingerprint: "not hex"})
+	if err := conn.Connect(); err == nil || !strings.Contains(err.Error(), "invalid fingerprint") {
+		t.Errorf("Connect error = %v, want invalid fingerprint", err)
+	}
+}
+
+func TestZOSMFClientCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	var gotAuth, gotCN string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotCN = r.TLS.PeerCertificates[0].Subject.CommonName
+		fmt.Fprint(w, `{"items":[]}`)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pki.pool}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	ca := testServerCA(t, srv)
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr bool
+	}{
+		{"pem", TLSOptions{CAFile: ca, CertFile: pki.certFile, KeyFile: pki.keyFile}, false},
+		{"pkcs12", TLSOptions{CAFile: ca, CertFile: pki.p12File, CertPassword: testP12Password}, false},
+		{"no certificate", TLSOptions{CAFile: ca}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAuth, gotCN = "", ""
+			conn := testZOSMFConnection(t, srv, tt.opts)
+			if err := conn.Connect(); err != nil {
+				t.Fatalf("Connect error: %v", err)
+			}
+			defer conn.Close()
+
+			_, err := conn.ListDatasets("IBMUSER")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error without client certificate")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListDatasets error: %v", err)
+			}
+			if gotCN != "IBMUSER" {
+				t.Errorf("client certificate CN = %q, want IBMUSER", gotCN)
+			}
+			if gotAuth != "" {
+				t.Errorf("Authorization = %q, want none with a client certificate", gotAuth)
+			}
+		})
+	}
+}
+
+func colonHex(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = hex.EncodeToString([]byte{c})
+	}
+	return strings.Join(parts, ":")
+}
+
+func TestZOSMFSubmitMember(t *testing.T) {
+	var gotBody map[string]string
+	var gotType string
+	conn := newTestZOSMF(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/zosmf/restjobs/jobs" {
+			http.NotFound(w, r)
+			return
+		}
+		gotType = r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"jobid":"JOB00042","jobname":"MYJOB"}`)
+	})
+
+	jobid, err := conn.SubmitMember("'USER.JCL'", "MYJOB")
+	if err != nil {
+		t.Fatalf("SubmitMember error: %v", err)
+	}
+	if jobid != "JOB00042" {
+		t.Errorf("jobid = %q, want JOB00042", jobid)
+	}
+	if gotType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", gotType)
+	}
+	if gotBody["file"] != "//'USER.JCL(MYJOB)'" {
+		t.Errorf("file = %q, want //'USER.JCL(MYJOB)'", gotBody["file"])
+	}
+}
+
+func TestZOSMFSubmitMemberError(t *testing.T) {
+	conn := newTestZOSMF(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"message":"Dataset not found"}`)
+	})
+
+	_, err := conn.SubmitMember("USER.JCL", "NOPE")
+	if err == nil || !strings.Contains(err.Error(), "Dataset not found") {
+		t.Errorf("error = %v, want z/OSMF message", err)
+	}
+}
+
+func TestZOSMFListJobsQuery(t *testing.T) {
+	var query url.Values
+	conn := newTestZOSMF(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `[
+			{"jobid":"JOB00001","jobname":"PAYA","owner":"BATCH","status":"OUTPUT","retcode":"CC 0000","class":"A"},
+			{"jobid":"JOB00002","jobname":"PAYB","owner":"BATCH","status":"OUTPUT","retcode":"ABEND S0C4","class":"A"}
+		]`)
+	})
+
+	jobs, err := conn.ListJobs(JobFilter{Owner: "BATCH", Prefix: "pay", MaxJobs: 50})
+	if err != nil {
+		t.Fatalf("ListJobs error: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(jobs))
+	}
+	if query.Get("owner") != "BATCH" || query.Get("prefix") != "PAY*" || query.Get("max-jobs") != "50" {
+		t.Errorf("query = %v", query)
+	}
+
+	jobs, err = conn.ListJobs(JobFilter{RetCode: "failed", MaxJobs: 50})
+	if err != nil {
+		t.Fatalf("ListJobs error: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].JobID != "JOB00002" {
+		t.Errorf("failed filter = %+v, want JOB00002 only", jobs)
+	}
+	if query.Get("owner") != "user" || query.Has("max-jobs") {
+		t.Errorf("query = %v, want default owner and no server-side limit", query)
+	}
+}
+
+func TestZOSMFListJobsLimitByStatus(t *testing.T) {
+	var query url.Values
+	conn := newTestZOSMF(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		items := []jobsListResponse{
+			{JobID: "JOB00004", JobName: "PAYD", Owner: "USER", Status: "ACTIVE"},
+			{JobID: "JOB00003", JobName: "PAYC", Owner: "USER", Status: "ACTIVE"},
+			{JobID: "JOB00002", JobName: "PAYB", Owner: "USER", Status: "OUTPUT"},
+			{JobID: "JOB00001", JobName: "PAYA", Owner: "USER", Status: "OUTPUT"},
+		}
+		if n, err := strconv.Atoi(query.Get("max-jobs")); err == nil && n < len(items) {
+			items = items[:n]
+		}
+		json.NewEncoder(w).Encode(items)
+	})
+
+	jobs, err := conn.ListJobs(JobFilter{Status: "OUTPUT", MaxJobs: 2})
+	if err != nil {
+		t.Fatalf("ListJobs error: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].JobID != "JOB00002" || jobs[1].JobID != "JOB00001" {
+		t.Errorf("OUTPUT jobs = %+v, want JOB00002 and JOB00001", jobs)
+	}
+	if query.Has("max-jobs") {
+		t.Errorf("query = %v, want no server-side limit", query)
+	}
+
+	jobs, err = conn.ListJobs(JobFilter{Status: "ACTIVE", MaxJobs: 2})
+	if err != nil {
+		t.Fatalf("ListJobs error: %v", err)
+	}
+	if len(jobs) != 2 || query.Get("max-jobs") != "2" || query.Get("status") != "active" {
+		t.Errorf("ACTIVE jobs = %+v, query = %v", jobs, query)
+	}
+}
+
+func TestZOSMFSpoolFiles(t *testing.T) {
+	conn := newTestZOSMF(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/zosmf/restjobs/jobs":
+			fmt.Fprint(w, `[{"jobid":"JOB00001","jobname":"MYJOB","status":"OUTPUT","retcode":"CC 0000",
+				"exec-submitted":"2025-06-14T10:52:33.120Z","exec-ended":"2025-06-14T10:53:01.000Z"}]`)
+		case "/zosmf/restjobs/jobs/MYJOB/JOB00001/files":
+			fmt.Fprint(w, `[{"id":2,"ddname":"JESMSGLG","stepname":"JES2","class":"H","byte-count":1200,"record-count":20},
+				{"id":102,"ddname":"SYSPRINT","stepname":"COMPILE","procstep":"COBOL","class":"H","byte-count":300,"record-count":5}]`)
+		case "/zosmf/restjobs/jobs/MYJOB/JOB00001/files/102/records":
+			fmt.Fprint(w, "compiler listing\n")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	status, err := conn.GetJobStatus("JOB00001")
+	if err != nil {
+		t.Fatalf("GetJobStatus error: %v", err)
+	}
+	if status.Submitted.IsZero() || status.Ended.Sub(status.Submitted) <= 0 {
+		t.Errorf("timing not parsed: %+v", status)
+	}
+
+	files, err := conn.ListSpoolFiles("JOB00001")
+	if err != nil {
+		t.Fatalf("ListSpoolFiles error: %v", err)
+	}
+	if len(files) != 2 || files[1].ProcStep != "COBOL" || files[1].Records != 5 {
+		t.Errorf("files = %+v", files)
+	}
+
+	data, err := conn.ReadSpoolFile("JOB00001", 102)
+	if err != nil {
+		t.Fatalf("ReadSpoolFile error: %v", err)
+	}
+	if string(data) != "compiler listing\n" {
+		t.Errorf("data = %q", data)
+	}
+}
+
+func TestZOSMFGetJobJCL(t *testing.T) {
+	conn := newTestZOSMF(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/zosmf/restjobs/jobs":
+			fmt.Fprint(w, `[{"jobid":"JOB00001","jobname":"MYJOB","status":"OUTPUT","retcode":"CC 0000"}]`)
+		case "/zosmf/restjobs/jobs/MYJOB/JOB00001/files/JCL/records":
+			fmt.Fprint(w, "//MYJOB JOB CLASS=A\n//S1 EXEC PGM=IEFBR14\n")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	jcl, err := conn.GetJobJCL("JOB00001")
+	if err != nil {
+		t.Fatalf("GetJobJCL error: %v", err)
+	}
+	if string(jcl) != "//MYJOB JOB CLASS=A\n//S1 EXEC PGM=IEFBR14\n" {
+		t.Errorf("GetJobJCL() = %q", jcl)
+	}
+}
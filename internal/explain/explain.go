@@ -0,0 +1,130 @@
+package explain
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed catalog.yaml
+var builtin []byte
+
+// Entry explains one abend code or message ID.
+type Entry struct {
+	Summary string `yaml:"summary"`
+	Cause   string `yaml:"cause"`
+}
+
+// Catalog maps abend codes (S0C7, U4038) and message IDs (IEC141I) to
+// explanations.
+type Catalog struct {
+	entries map[string]Entry
+}
+
+// Message is a catalogued message found in job output.
+type Message struct {
+	ID string
+	Entry
+}
+
+// messageRe matches IBM style message IDs such as IEC141I, IGZ0035S or
+// CEE3204S at the start of a word.
+var messageRe = regexp.MustCompile(`\b([A-Z]{3,5}\d{3,5}[A-Z])\b`)
+
+// Load returns the built-in catalog, extended by the YAML file at path
+// if it is not empty. Entries in the file override built-in ones.
+func Load(path string) (*Catalog, error) {
+	c := &Catalog{entries: make(map[string]Entry)}
+	if err := c.add(builtin); err != nil {
+		return nil, fmt.Errorf("invalid built-in catalog: %w", err)
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read catalog: %w", err)
+		}
+		if err := c.add(data); err != nil {
+			return nil, fmt.Errorf("invalid catalog %s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *Catalog) add(data []byte) error {
+	var raw map[string]Entry
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for code, e := range raw {
+		c.entries[normalize(code)] = e
+	}
+	return nil
+}
+
+// Lookup explains an abend code or message ID. Return codes as reported by
+// JES ("ABEND S0C7") and unprefixed system codes ("0C7") are accepted.
+func (c *Catalog) Lookup(code string) (Entry, bool) {
+	if c == nil {
+		return Entry{}, false
+	}
+	e, ok := c.entries[normalize(code)]
+	return e, ok
+}
+
+// Messages returns the catalogued messages that appear in output, in order
+// of first appearance.
+func (c *Catalog) Messages(output []byte) []Message {
+	if c == nil {
+		return nil
+	}
+	var msgs []Message
+	seen := make(map[string]bool)
+	for _, id := range messageRe.FindAllString(string(output), -1) {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if e, ok := c.entries[id]; ok {
+			msgs = append(msgs, Message{ID: id, Entry: e})
+		}
+	}
+	return msgs
+}
+
+// normalize turns "ABEND S0C7", "s0c7", "0C7" and "U04038" into the catalog
+// form S0C7 / U4038.
+func normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.TrimSpace(strings.TrimPrefix(code, "ABEND"))
+
+	switch {
+	case len(code) == 3 && isHex(code):
+		return "S" + code
+	case len(code) == 6 && code[0] == 'U' && code[1] == '0' && isDigits(code[1:]):
+		return "U" + code[2:]
+	}
+	return code
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789ABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
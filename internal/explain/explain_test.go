@@ -0,0 +1,96 @@
+package explain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	cat, err := Load("")
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	tests := []struct {
+		code    string
+		want    string
+		wantHit bool
+	}{
+		{code: "S0C7", want: "Data exception", wantHit: true},
+		{code: "ABEND S0C7", want: "Data exception", wantHit: true},
+		{code: "s806", want: "Module not found", wantHit: true},
+		{code: "0C4", want: "Protection exception", wantHit: true},
+		{code: "U04038", want: "Language Environment severe error", wantHit: true},
+		{code: "IEC141I", want: "Dataset open failed (S013)", wantHit: true},
+		{code: "CC 0008"},
+		{code: "U1234"},
+		{code: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			e, ok := cat.Lookup(tt.code)
+			if ok != tt.wantHit || e.Summary != tt.want {
+				t.Errorf("Lookup(%q) = %q, %v; want %q, %v", tt.code, e.Summary, ok, tt.want, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestLoadUserCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "codes.yaml")
+	src := `U1234:
+  summary: Payroll input missing
+  cause: The daily payroll extract has not arrived yet.
+s0c7:
+  summary: Bad packed data
+  cause: Check the input file.
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cat, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if e, ok := cat.Lookup("ABEND U1234"); !ok || e.Summary != "Payroll input missing" {
+		t.Errorf("user code = %+v, %v", e, ok)
+	}
+	if e, _ := cat.Lookup("S0C7"); e.Summary != "Bad packed data" {
+		t.Errorf("user entry should override built-in, got %q", e.Summary)
+	}
+	if _, ok := cat.Lookup("S806"); !ok {
+		t.Error("built-in entries should still be present")
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing catalog")
+	}
+}
+
+func TestMessages(t *testing.T) {
+	cat, err := Load("")
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	output := []byte(`IEF236I ALLOC. FOR MYJOB STEP1
+IEC141I 013-18,IGG0191B,MYJOB,STEP1,INFILE,,,MY.DATA(NOPE)
+IGZ0035S There was an unsuccessful OPEN or CLOSE of file INFILE
+IEC141I 013-18 again
+`)
+	msgs := cat.Messages(output)
+	if len(msgs) != 2 {
+		t.Fatalf("Messages() = %+v, want 2", msgs)
+	}
+	if msgs[0].ID != "IEC141I" || msgs[1].ID != "IGZ0035S" {
+		t.Errorf("Messages() = %+v", msgs)
+	}
+
+	var nilCat *Catalog
+	if msgs := nilCat.Messages(output); msgs != nil {
+		t.Errorf("nil catalog should explain nothing, got %+v", msgs)
+	}
+}
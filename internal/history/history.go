@@ -0,0 +1,221 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zm/internal/connection"
+	"zm/internal/spool"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	DefaultDir  = ".zm"
+	DefaultFile = "history.db"
+)
+
+var (
+	jobsBucket  = []byte("jobs")  // sequence -> Record
+	indexBucket = []byte("index") // profile NUL jobid -> sequence of the latest record
+)
+
+// ErrNotFound is returned when a job is not in the history.
+var ErrNotFound = errors.New("job not found in history")
+
+// Record is a job submitted by zm.
+type Record struct {
+	ID          uint64       `json:"id"`
+	JobID       string       `json:"jobid"`
+	JobName     string       `json:"jobname,omitempty"`
+	Source      string       `json:"source"` // what was passed to submit: DS(MEMBER), file, USS path or "-"
+	Profile     string       `json:"profile"`
+	Submitted   time.Time    `json:"submitted"`
+	Completed   time.Time    `json:"completed"`
+	RetCode     string       `json:"retcode,omitempty"`
+	Steps       []spool.Step `json:"steps,omitempty"`
+	SpoolDigest string       `json:"spool_digest,omitempty"` // sha256 of the job output
+	SpoolBytes  int          `json:"spool_bytes,omitempty"`
+	JCL         string       `json:"jcl,omitempty"`
+}
+
+// Query selects records from the history. Zero values match everything.
+type Query struct {
+	Profile string
+	JobName string // job name prefix
+	Source  string // substring of the source
+	RetCode string // as in connection.JobFilter
+	Since   time.Time
+	Pending bool // only jobs whose completion was not recorded
+	Limit   int
+}
+
+// DB is the local job history.
+type DB struct {
+	bolt *bolt.DB
+}
+
+// DefaultPath returns ~/.zm/history.db.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find home directory: %w", err)
+	}
+	return filepath.Join(home, DefaultDir, DefaultFile), nil
+}
+
+// Open opens or creates the history database at path.
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	// Another zm process may hold the lock; don't block forever
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(jobsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(indexBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize history: %w", err)
+	}
+	return &DB{bolt: db}, nil
+}
+
+func (db *DB) Close() error {
+	return db.bolt.Close()
+}
+
+// Add stores a new record and sets its ID.
+func (db *DB) Add(rec *Record) error {
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(jobsBucket)
+		seq, err := jobs.NextSequence()
+		if err != nil {
+			return err
+		}
+		rec.ID = seq
+		if err := putRecord(jobs, rec); err != nil {
+			return err
+		}
+		return tx.Bucket(indexBucket).Put(indexKey(rec.Profile, rec.JobID), seqKey(seq))
+	})
+}
+
+// Update applies fn to the latest record of jobid in profile and stores it.
+func (db *DB) Update(profile, jobid string, fn func(*Record)) error {
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(jobsBucket)
+		rec, err := latest(tx, profile, jobid)
+		if err != nil {
+			return err
+		}
+		fn(rec)
+		return putRecord(jobs, rec)
+	})
+}
+
+// Get returns the latest record of jobid in profile.
+func (db *DB) Get(profile, jobid string) (*Record, error) {
+	var rec *Record
+	err := db.bolt.View(func(tx *bolt.Tx) error {
+		var err error
+		rec, err = latest(tx, profile, jobid)
+		return err
+	})
+	return rec, err
+}
+
+// List returns the records matching q, newest first.
+func (db *DB) List(q Query) ([]Record, error) {
+	var records []Record
+	err := db.bolt.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(jobsBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("corrupt history record %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			if !q.match(rec) {
+				continue
+			}
+			records = append(records, rec)
+			if q.Limit > 0 && len(records) == q.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+func (q Query) match(rec Record) bool {
+	if q.Profile != "" && rec.Profile != q.Profile {
+		return false
+	}
+	if q.JobName != "" && !strings.HasPrefix(rec.JobName, strings.ToUpper(strings.TrimSuffix(q.JobName, "*"))) {
+		return false
+	}
+	if q.Source != "" && !strings.Contains(strings.ToUpper(rec.Source), strings.ToUpper(q.Source)) {
+		return false
+	}
+	if q.RetCode != "" && !connection.MatchRetCode(rec.RetCode, q.RetCode) {
+		return false
+	}
+	if !q.Since.IsZero() && rec.Submitted.Before(q.Since) {
+		return false
+	}
+	if q.Pending && !rec.Completed.IsZero() {
+		return false
+	}
+	return true
+}
+
+func latest(tx *bolt.Tx, profile, jobid string) (*Record, error) {
+	seq := tx.Bucket(indexBucket).Get(indexKey(profile, jobid))
+	if seq == nil {
+		return nil, fmt.Errorf("%s: %w", jobid, ErrNotFound)
+	}
+	data := tx.Bucket(jobsBucket).Get(seq)
+	if data == nil {
+		return nil, fmt.Errorf("%s: %w", jobid, ErrNotFound)
+	}
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("corrupt history record for %s: %w", jobid, err)
+	}
+	return &rec, nil
+}
+
+func putRecord(jobs *bolt.Bucket, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return jobs.Put(seqKey(rec.ID), data)
+}
+
+// seqKey encodes a sequence big-endian so records sort in submission order.
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+func indexKey(profile, jobid string) []byte {
+	return []byte(profile + "\x00" + strings.ToUpper(jobid))
+}
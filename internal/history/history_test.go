@@ -0,0 +1,126 @@
+package history
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "zm", DefaultFile))
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestAddUpdateGet(t *testing.T) {
+	db := openTestDB(t)
+
+	rec := &Record{JobID: "JOB00001", JobName: "PAYROLL", Source: "MY.JCL(PAYROLL)", Profile: "prod",
+		Submitted: time.Now(), JCL: "//PAYROLL JOB\n"}
+	if err := db.Add(rec); err != nil {
+		t.Fatalf("Add error: %v", err)
+	}
+	if rec.ID == 0 {
+		t.Error("Add should assign an ID")
+	}
+
+	err := db.Update("prod", "job00001", func(r *Record) {
+		r.RetCode = "CC 0004"
+		r.Completed = r.Submitted.Add(time.Minute)
+	})
+	if err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+
+	got, err := db.Get("prod", "JOB00001")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if got.RetCode != "CC 0004" || got.JCL != "//PAYROLL JOB\n" || got.Completed.IsZero() {
+		t.Errorf("Get() = %+v", got)
+	}
+
+	if _, err := db.Get("test", "JOB00001"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get from another profile: error = %v, want ErrNotFound", err)
+	}
+	if err := db.Update("prod", "JOB99999", func(*Record) {}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of unknown job: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetReturnsLatest(t *testing.T) {
+	db := openTestDB(t)
+
+	// JES reuses job numbers, the newest submission wins
+	for _, rc := range []string{"CC 0000", "CC 0008"} {
+		if err := db.Add(&Record{JobID: "JOB00001", Profile: "prod", RetCode: rc}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := db.Get("prod", "JOB00001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RetCode != "CC 0008" {
+		t.Errorf("Get() = %+v, want latest record", got)
+	}
+}
+
+func TestList(t *testing.T) {
+	db := openTestDB(t)
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{JobID: "JOB00001", JobName: "PAYROLL", Source: "MY.JCL(PAYROLL)", Profile: "prod", Submitted: base, Completed: base, RetCode: "CC 0000"},
+		{JobID: "JOB00002", JobName: "PAYROLL", Source: "MY.JCL(PAYROLL)", Profile: "prod", Submitted: base.Add(24 * time.Hour), Completed: base, RetCode: "ABEND S0C7"},
+		{JobID: "JOB00003", JobName: "BACKUP", Source: "backup.jcl", Profile: "prod", Submitted: base.Add(48 * time.Hour)},
+		{JobID: "JOB00004", JobName: "PAYROLL", Source: "MY.JCL(PAYROLL)", Profile: "test", Submitted: base.Add(72 * time.Hour), Completed: base, RetCode: "CC 0000"},
+	}
+	for i := range records {
+		if err := db.Add(&records[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{name: "all newest first", q: Query{}, want: []string{"JOB00004", "JOB00003", "JOB00002", "JOB00001"}},
+		{name: "profile", q: Query{Profile: "prod"}, want: []string{"JOB00003", "JOB00002", "JOB00001"}},
+		{name: "last success", q: Query{Profile: "prod", JobName: "pay*", RetCode: "<=4", Limit: 1}, want: []string{"JOB00001"}},
+		{name: "source", q: Query{Source: "payroll"}, want: []string{"JOB00004", "JOB00002", "JOB00001"}},
+		{name: "failed", q: Query{RetCode: "failed"}, want: []string{"JOB00002"}},
+		{name: "since", q: Query{Since: base.Add(36 * time.Hour)}, want: []string{"JOB00004", "JOB00003"}},
+		{name: "pending", q: Query{Pending: true}, want: []string{"JOB00003"}},
+		{name: "limit", q: Query{Limit: 2}, want: []string{"JOB00004", "JOB00003"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.List(tt.q)
+			if err != nil {
+				t.Fatalf("List error: %v", err)
+			}
+			var ids []string
+			for _, r := range got {
+				ids = append(ids, r.JobID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("List() = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Errorf("List() = %v, want %v", ids, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
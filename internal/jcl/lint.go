@@ -0,0 +1,368 @@
+package jcl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in a JCL member.
+type Diagnostic struct {
+	Line     int // 1-based, 0 if unknown
+	Severity Severity
+	Message  string
+}
+
+var jobKeywords = keywordSet(
+	"ADDRSPC", "BYTES", "CARDS", "CCSID", "CLASS", "COND", "DSENQSHR", "EMAIL",
+	"GROUP", "JESLOG", "JOBRC", "LINES", "MEMLIMIT", "MSGCLASS", "MSGLEVEL",
+	"NOTIFY", "PAGES", "PASSWORD", "PERFORM", "PRTY", "RD", "REGION", "REGIONX",
+	"RESTART", "SCHENV", "SECLABEL", "SYSAFF", "SYSTEM", "TIME", "TYPRUN",
+	"UJOBCORR", "USER",
+)
+
+var execKeywords = keywordSet(
+	"PGM", "PROC", "ACCT", "ADDRSPC", "CCSID", "COND", "DYNAMNBR", "MEMLIMIT",
+	"PARM", "PARMDD", "PERFORM", "RD", "REGION", "REGIONX", "RLSTMOUT", "TIME",
+	"TVSMSG", "TVSAMCOM",
+)
+
+var ddKeywords = keywordSet(
+	"ACCODE", "AMP", "AVGREC", "BLKSIZE", "BLKSZLIM", "BURST", "CCSID", "CHARS",
+	"CHKPT", "CNTL", "COPIES", "DATACLAS", "DCB", "DDNAME", "DEST", "DISP", "DLM",
+	"DSID", "DSKEYLBL", "DSN", "DSNAME", "DSNTYPE", "EATTR", "EXPDT", "FCB",
+	"FILEDATA", "FLASH", "FREE", "FREEVOL", "GDGORDER", "HOLD", "KEYENCD1",
+	"KEYENCD2", "KEYLABL1", "KEYLABL2", "KEYLEN", "KEYOFF", "LABEL", "LGSTREAM",
+	"LIKE", "LRECL", "MAXGENS", "MGMTCLAS", "MODIFY", "OUTLIM", "OUTPUT", "PATH",
+	"PATHDISP", "PATHMODE", "PATHOPTS", "PROTECT", "QNAME", "RECFM", "RECORG",
+	"REFDD", "RETPD", "RLS", "ROACCESS", "SECMODEL", "SEGMENT", "SPACE", "SPIN",
+	"STORCLAS", "SUBSYS", "SYMBOLS", "SYMLIST", "SYSOUT", "TERM", "UCS", "UNIT",
+	"VOL", "VOLUME",
+	// DCB subparameters may be coded directly on the DD statement
+	"BFALN", "BFTEK", "BUFIN", "BUFL", "BUFMAX", "BUFNO", "BUFOFF", "BUFOUT",
+	"BUFSIZE", "CPRI", "CYLOFL", "DEN", "DIAGNS", "DSORG", "EROPT", "FUNC",
+	"GNCP", "INTVL", "IPLTXID", "LIMCT", "MODE", "NCP", "NTM", "OPTCD", "PCI",
+	"PRTSP", "RESERVE", "RKP", "STACK", "THRESH", "TRTCH",
+)
+
+// dynamicSymbols are JES-provided symbols that need no definition.
+var dynamicSymbols = keywordSet(
+	"DAY", "HHMMSS", "HR", "JDAY", "LDAY", "LHHMMSS", "LHR", "LJDAY", "LMIN",
+	"LMON", "LSEC", "LWDAY", "LYR2", "LYR4", "LYYMMDD", "MIN", "MON", "SEC",
+	"SEQ", "WDAY", "YR2", "YR4", "YYMMDD",
+)
+
+// Lint checks JCL without contacting the host.
+func Lint(src []byte) []Diagnostic {
+	s := parse(src)
+	s.check()
+
+	sort.SliceStable(s.diags, func(i, j int) bool {
+		return s.diags[i].Line < s.diags[j].Line
+	})
+	return s.diags
+}
+
+// HasErrors reports whether diags contains at least one error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *script) check() {
+	if len(s.statements) == 0 {
+		s.errorf(0, "no JCL statements found")
+		return
+	}
+
+	procMember := s.statements[0].op == "PROC"
+	if first := s.statements[0]; first.op != "JOB" && !procMember {
+		s.errorf(first.line, "first statement must be a JOB statement, found %s", first.op)
+	}
+
+	defined := s.definedSymbols()
+	steps := make(map[string]int)
+	var prev *statement
+	inProc := procMember
+	ifDepth := 0
+	seenExec := false
+
+	for _, st := range s.statements {
+		if st.broken {
+			prev = st
+			continue
+		}
+
+		switch st.op {
+		case "JOB":
+			s.checkName(st, true)
+			s.checkKeywords(st, jobKeywords)
+			seenExec = false
+			steps = make(map[string]int)
+
+		case "EXEC":
+			s.checkExec(st)
+			if st.name != "" {
+				if line, dup := steps[st.name]; dup && !inProc {
+					s.warnf(st.line, "step name %s already used on line %d", st.name, line)
+				}
+				steps[st.name] = st.line
+			}
+			seenExec = true
+
+		case "DD":
+			s.checkDD(st, prev, seenExec || inProc)
+
+		case "PROC":
+			if !procMember && st.name == "" {
+				s.errorf(st.line, "in-stream PROC statement requires a name")
+			}
+			inProc = true
+
+		case "PEND":
+			if !inProc || procMember {
+				s.errorf(st.line, "PEND without a matching PROC")
+			}
+			inProc = procMember
+
+		case "IF":
+			ifDepth++
+
+		case "ENDIF":
+			if ifDepth == 0 {
+				s.errorf(st.line, "ENDIF without a matching IF")
+			} else {
+				ifDepth--
+			}
+
+		case "SET":
+			for _, p := range st.params {
+				if p.keyword == "" {
+					s.errorf(st.line, "SET requires NAME=VALUE parameters")
+				}
+			}
+		}
+
+		s.checkSymbols(st, defined, procMember)
+		prev = st
+	}
+
+	if inProc && !procMember {
+		s.errorf(s.statements[len(s.statements)-1].lastLine, "in-stream PROC is not ended by PEND")
+	}
+	if ifDepth > 0 {
+		s.errorf(s.statements[len(s.statements)-1].lastLine, "IF without a matching ENDIF")
+	}
+}
+
+func (s *script) checkExec(st *statement) {
+	s.checkName(st, false)
+
+	pgm := st.param("PGM")
+	if pgm == nil && st.param("PROC") == nil && st.positional() == "" {
+		s.errorf(st.line, "EXEC requires PGM=, PROC= or a procedure name")
+		return
+	}
+	// Keywords on a procedure call may be symbolic parameter overrides
+	if pgm != nil {
+		s.checkKeywords(st, execKeywords)
+	}
+}
+
+func (s *script) checkDD(st, prev *statement, inStep bool) {
+	if st.name == "" {
+		if prev == nil || prev.op != "DD" {
+			s.errorf(st.line, "unnamed DD statement must follow another DD (concatenation)")
+		}
+	} else {
+		s.checkName(st, false)
+		if !inStep && st.name != "JOBLIB" && st.name != "JOBCAT" {
+			s.errorf(st.line, "DD statement %s appears before the first EXEC", st.name)
+		}
+	}
+	s.checkKeywords(st, ddKeywords)
+}
+
+func (s *script) checkName(st *statement, required bool) {
+	if st.name == "" {
+		if required {
+			s.errorf(st.line, "%s statement requires a name", st.op)
+		}
+		return
+	}
+
+	parts := []string{st.name}
+	if st.op == "DD" {
+		parts = strings.Split(st.name, ".")
+	}
+	for _, part := range parts {
+		if !validJCLName(part) {
+			s.errorf(st.line, "invalid name %q (1-8 characters, starting with A-Z, @, # or $)", st.name)
+			return
+		}
+	}
+}
+
+func (s *script) checkKeywords(st *statement, valid map[string]bool) {
+	for _, p := range st.params {
+		if p.keyword == "" {
+			continue
+		}
+		kw := p.keyword
+		if st.op == "EXEC" {
+			// PARM.STEP1=, COND.STEP2= override procedure steps
+			kw, _, _ = strings.Cut(kw, ".")
+		}
+		if !valid[kw] {
+			line, _ := st.position(p.offset)
+			s.errorf(line, "invalid keyword %s on %s statement", p.keyword, st.op)
+		}
+	}
+}
+
+func (s *script) checkSymbols(st *statement, defined map[string]bool, procMember bool) {
+	for _, ref := range symbolRefs(st.operands) {
+		name := strings.ToUpper(ref.name)
+		if defined[name] || dynamicSymbols[name] || strings.HasPrefix(name, "SYS") {
+			continue
+		}
+		line, _ := st.position(ref.offset)
+		if procMember {
+			s.warnf(line, "symbol &%s has no default on the PROC statement", name)
+		} else {
+			s.errorf(line, "undefined symbolic parameter &%s", name)
+		}
+	}
+}
+
+// definedSymbols collects names set by SET, PROC defaults and EXEC overrides.
+func (s *script) definedSymbols() map[string]bool {
+	defined := make(map[string]bool)
+	for _, st := range s.statements {
+		switch st.op {
+		case "SET", "PROC":
+			for _, p := range st.params {
+				if p.keyword != "" {
+					defined[p.keyword] = true
+				}
+			}
+		case "EXEC":
+			if st.param("PGM") != nil {
+				continue
+			}
+			for _, p := range st.params {
+				kw, _, _ := strings.Cut(p.keyword, ".")
+				if kw != "" && !execKeywords[kw] {
+					defined[kw] = true
+				}
+			}
+		}
+	}
+	return defined
+}
+
+type symbolRef struct {
+	name   string
+	offset int
+}
+
+// symbolRefs finds &NAME references outside apostrophes, skipping &&TEMP names.
+func symbolRefs(ops string) []symbolRef {
+	var refs []symbolRef
+	quoted := false
+	for i := 0; i < len(ops); i++ {
+		switch c := ops[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted || c != '&':
+		case i+1 < len(ops) && ops[i+1] == '&':
+			i += 1 + nameLen(ops[i+2:])
+		default:
+			n := nameLen(ops[i+1:])
+			if n > 0 {
+				refs = append(refs, symbolRef{name: ops[i+1 : i+1+n], offset: i})
+			}
+			i += n
+		}
+	}
+	return refs
+}
+
+func validJCLName(name string) bool {
+	if len(name) == 0 || len(name) > 8 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c == '@', c == '#', c == '$':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func keywordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+func (s *script) errorf(line int, format string, args ...interface{}) {
+	s.diags = append(s.diags, Diagnostic{Line: line, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *script) warnf(line int, format string, args ...interface{}) {
+	s.diags = append(s.diags, Diagnostic{Line: line, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// scanMessageRe matches JESYSMSG lines like "   3 IEFC605I UNIDENTIFIED OPERATION FIELD".
+var scanMessageRe = regexp.MustCompile(`^\s*(\d+)\s+(IEF[A-Z]?\d{3,4}[A-Z])\s+(.*)$`)
+
+// ParseScanMessages extracts the errors JES reported for a TYPRUN=SCAN job
+// and maps their statement numbers back to lines of src.
+func ParseScanMessages(spool, src []byte) []Diagnostic {
+	lineOf := make(map[int]int)
+	for _, st := range parse(src).statements {
+		lineOf[st.number] = st.line
+	}
+
+	var diags []Diagnostic
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(spool), "\n") {
+		m := scanMessageRe.FindStringSubmatch(strings.TrimRight(line, "\r "))
+		if m == nil {
+			continue
+		}
+		key := m[1] + " " + m[2] + " " + m[3]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		stmt, _ := strconv.Atoi(m[1])
+		diags = append(diags, Diagnostic{
+			Line:     lineOf[stmt],
+			Severity: SeverityError,
+			Message:  m[2] + " " + m[3],
+		})
+	}
+	return diags
+}
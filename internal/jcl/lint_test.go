@@ -0,0 +1,204 @@
+package jcl
+
+import (
+	"strings"
+	"testing"
+)
+
+const validJCL = `//FALZONEA JOB (ACCT),'FALZONE',CLASS=A,MSGCLASS=H,
+//             NOTIFY=&SYSUID
+//* comment
+//         SET HLQ=FALZONE
+//MYPROC   PROC MEMBER=X
+//PS1      EXEC PGM=IEBGENER
+//SYSUT1   DD DSN=&HLQ..SOURCE(&MEMBER),DISP=SHR
+//         PEND
+//STEP1    EXEC PGM=IEFBR14,PARM='A B,C'
+//DD1      DD DSN=&HLQ..TEST,DISP=(NEW,CATLG,DELETE),
+//            SPACE=(TRK,(1,1)),UNIT=SYSDA
+//         DD DSN=&&TEMP,DISP=(NEW,PASS)
+//SYSIN    DD *
+ in-stream data
+/*
+//STEP2    EXEC MYPROC,MEMBER=Y
+//PS1.SYSUT1 DD DUMMY
+// IF (STEP1.RC = 0) THEN
+//STEP3    EXEC PGM=IKJEFT01
+//SYSTSIN  DD DATA,DLM=@@
+//NOTJCL looks like JCL but is data
+@@
+// ENDIF
+`
+
+func TestLintValid(t *testing.T) {
+	if diags := Lint([]byte(validJCL)); len(diags) != 0 {
+		t.Errorf("Lint() = %+v, want no diagnostics", diags)
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		jcl      string
+		wantLine int
+		wantMsg  string
+	}{
+		{
+			name:     "first statement not JOB",
+			jcl:      "//STEP1 EXEC PGM=IEFBR14\n",
+			wantLine: 1,
+			wantMsg:  "first statement must be a JOB",
+		},
+		{
+			name:     "invalid job name",
+			jcl:      "//badjob JOB CLASS=A\n",
+			wantLine: 1,
+			wantMsg:  "invalid name",
+		},
+		{
+			name:     "invalid keyword",
+			jcl:      "//J JOB CLASS=A,MSGCLAS=H\n",
+			wantLine: 1,
+			wantMsg:  "invalid keyword MSGCLAS",
+		},
+		{
+			name:     "invalid DD keyword on continuation",
+			jcl:      "//J JOB\n//S EXEC PGM=X\n//D DD DSN=A,\n//  DISPO=SHR\n",
+			wantLine: 4,
+			wantMsg:  "invalid keyword DISPO",
+		},
+		{
+			name:     "unknown operation",
+			jcl:      "//J JOB\n//S EXECC PGM=X\n",
+			wantLine: 2,
+			wantMsg:  "unknown operation",
+		},
+		{
+			name:     "exec without program",
+			jcl:      "//J JOB\n//S EXEC REGION=0M\n",
+			wantLine: 2,
+			wantMsg:  "EXEC requires",
+		},
+		{
+			name:     "unbalanced quotes",
+			jcl:      "//J JOB\n//S EXEC PGM=X,PARM='ABC\n",
+			wantLine: 2,
+			wantMsg:  "unbalanced quotes",
+		},
+		{
+			name:     "unbalanced parentheses",
+			jcl:      "//J JOB\n//S EXEC PGM=X\n//D DD DSN=A,DISP=(NEW,CATLG\n",
+			wantLine: 3,
+			wantMsg:  "unbalanced parentheses",
+		},
+		{
+			name:     "missing continuation",
+			jcl:      "//J JOB\n//S EXEC PGM=X,\n",
+			wantLine: 2,
+			wantMsg:  "expected continuation",
+		},
+		{
+			name:     "continuation too far right",
+			jcl:      "//J JOB\n//S EXEC PGM=X,\n//                  REGION=0M\n",
+			wantLine: 3,
+			wantMsg:  "between columns 4 and 16",
+		},
+		{
+			name:     "column 72 overflow",
+			jcl:      "//J JOB\n//S EXEC PGM=X\n//DD1      DD DSN=FALZONE.TEST.DATA,DISP=SHR,UNIT=SYSDA,VOL=SER=ABCDEFGHIJ\n",
+			wantLine: 3,
+			wantMsg:  "past column 71",
+		},
+		{
+			name:     "undefined symbol",
+			jcl:      "//J JOB\n//S EXEC PGM=X\n//D DD DSN=&NOPE..DATA,DISP=SHR\n",
+			wantLine: 3,
+			wantMsg:  "undefined symbolic parameter &NOPE",
+		},
+		{
+			name:     "data without DD *",
+			jcl:      "//J JOB\n//S EXEC PGM=X\n some data\n",
+			wantLine: 3,
+			wantMsg:  "does not start with //",
+		},
+		{
+			name:     "missing delimiter",
+			jcl:      "//J JOB\n//S EXEC PGM=X\n//SYSIN DD DATA,DLM=$$\n data\n",
+			wantLine: 3,
+			wantMsg:  "never terminated",
+		},
+		{
+			name:     "DD before EXEC",
+			jcl:      "//J JOB\n//D DD DSN=A,DISP=SHR\n",
+			wantLine: 2,
+			wantMsg:  "before the first EXEC",
+		},
+		{
+			name:     "PEND missing",
+			jcl:      "//J JOB\n//P PROC\n//S EXEC PGM=X\n",
+			wantLine: 3,
+			wantMsg:  "not ended by PEND",
+		},
+		{
+			name:     "IF without ENDIF",
+			jcl:      "//J JOB\n// IF (RC = 0) THEN\n//S EXEC PGM=X\n",
+			wantLine: 3,
+			wantMsg:  "without a matching ENDIF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := Lint([]byte(tt.jcl))
+			for _, d := range diags {
+				if d.Line == tt.wantLine && strings.Contains(d.Message, tt.wantMsg) {
+					return
+				}
+			}
+			t.Errorf("Lint() = %+v, want line %d with %q", diags, tt.wantLine, tt.wantMsg)
+		})
+	}
+}
+
+func TestLintProcMember(t *testing.T) {
+	src := "//MYPROC PROC HLQ=X\n//S EXEC PGM=IEFBR14\n//D DD DSN=&HLQ..&LLQ,DISP=SHR\n"
+
+	diags := Lint([]byte(src))
+	if len(diags) != 1 {
+		t.Fatalf("Lint() = %+v, want 1 diagnostic", diags)
+	}
+	if diags[0].Severity != SeverityWarning || !strings.Contains(diags[0].Message, "&LLQ") {
+		t.Errorf("diagnostic = %+v, want warning about &LLQ", diags[0])
+	}
+}
+
+func TestParseScanMessages(t *testing.T) {
+	src := "//J JOB CLASS=A\n//* comment\n//S1 EXEC PGM=X\n//S2 EXECC PGM=Y\n"
+	spool := `--- DD: JESYSMSG (Step: JES2) ---
+ STMT NO. MESSAGE
+        3 IEFC605I UNIDENTIFIED OPERATION FIELD
+--- DD: JESJCL (Step: JES2) ---
+        3 IEFC605I UNIDENTIFIED OPERATION FIELD
+        9 IEFC019I MISPLACED JOB STATEMENT
+`
+
+	diags := ParseScanMessages([]byte(spool), []byte(src))
+	if len(diags) != 2 {
+		t.Fatalf("ParseScanMessages() = %+v, want 2 diagnostics", diags)
+	}
+	if diags[0].Line != 4 || diags[0].Message != "IEFC605I UNIDENTIFIED OPERATION FIELD" {
+		t.Errorf("first diagnostic = %+v", diags[0])
+	}
+	if diags[1].Line != 0 {
+		t.Errorf("unknown statement should map to line 0, got %d", diags[1].Line)
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	if HasErrors([]Diagnostic{{Severity: SeverityWarning}}) {
+		t.Error("warnings only should not count as errors")
+	}
+	if !HasErrors([]Diagnostic{{Severity: SeverityWarning}, {Severity: SeverityError}}) {
+		t.Error("expected errors")
+	}
+}
@@ -0,0 +1,429 @@
+package jcl
+
+import (
+	"strings"
+)
+
+const (
+	lastColumn       = 71 // columns 72-80 are not part of the statement
+	recordLength     = 80
+	maxContinueStart = 16 // continuation text must start in columns 4-16
+)
+
+// statement is one JCL statement with its continuation records joined.
+type statement struct {
+	number   int // JES statement number, as shown in JESJCL/JESYSMSG
+	line     int // 1-based line of the first record
+	lastLine int // 1-based line of the last record
+	name     string
+	op       string
+	operands string
+	segments []segment
+	params   []param
+	broken   bool // operands could not be split into parameters
+}
+
+// segment maps a piece of the joined operand text back to its source line.
+type segment struct {
+	offset int // start offset in statement.operands
+	line   int // 1-based source line
+	col    int // 0-based column in the source line
+}
+
+type param struct {
+	keyword string // empty for positional parameters
+	value   string
+	offset  int // offset of the value in statement.operands
+}
+
+// script is a parsed JCL member.
+type script struct {
+	lines      []string
+	statements []*statement
+	diags      []Diagnostic
+}
+
+var operations = map[string]bool{
+	"JOB": true, "EXEC": true, "DD": true, "PROC": true, "PEND": true,
+	"SET": true, "IF": true, "ELSE": true, "ENDIF": true, "INCLUDE": true,
+	"JCLLIB": true, "OUTPUT": true, "CNTL": true, "ENDCNTL": true,
+	"EXPORT": true, "XMIT": true, "COMMAND": true, "SCHEDULE": true,
+	"NOTIFY": true, "JOBGROUP": true, "ENDGROUP": true, "GJOB": true,
+	"JOBSET": true, "SJOB": true, "ENDSET": true, "AFTER": true,
+	"BEFORE": true, "CONCURRENT": true,
+}
+
+// parse splits src into statements. Syntax problems found while splitting
+// (continuations, column 72, quotes, parentheses) are recorded in diags.
+func parse(src []byte) *script {
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	s := &script{lines: strings.Split(text, "\n")}
+	if text == "" {
+		s.lines = nil
+	}
+
+	var (
+		number   int
+		inStream bool
+		dlm      string // custom delimiter set with DLM=
+		dataMode bool   // DD DATA: lines starting with // are data too
+		streamAt int
+		ended    bool
+	)
+
+	for i := 0; i < len(s.lines); i++ {
+		line := s.lines[i]
+		lineNo := i + 1
+
+		if inStream {
+			switch {
+			case dlm != "" && strings.HasPrefix(line, dlm):
+				inStream = false
+				continue
+			case dlm == "" && isDelimiter(line):
+				inStream = false
+				continue
+			case dlm == "" && !dataMode && strings.HasPrefix(line, "//"):
+				inStream = false
+			default:
+				continue
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(line, "//*"):
+			continue
+		case strings.HasPrefix(line, "/*"):
+			if isDelimiter(line) {
+				s.warnf(lineNo, "delimiter /* without in-stream data")
+			}
+			// JES2 control statements such as /*JOBPARM are not checked
+			continue
+		case isNull(line):
+			ended = true
+			continue
+		case !strings.HasPrefix(line, "//"):
+			if strings.TrimSpace(line) == "" {
+				s.errorf(lineNo, "blank line; JCL statements must start with //")
+			} else {
+				s.errorf(lineNo, "statement does not start with // (missing DD * for in-stream data?)")
+			}
+			continue
+		}
+
+		if ended {
+			s.warnf(lineNo, "statement after the null statement (//) is ignored by JES")
+			ended = false
+		}
+
+		number++
+		st := &statement{number: number, line: lineNo}
+		i = s.parseStatement(st, i)
+		s.statements = append(s.statements, st)
+
+		if st.op == "DD" {
+			if first := st.positional(); first == "*" || first == "DATA" {
+				inStream = true
+				dataMode = first == "DATA"
+				dlm = strings.Trim(st.keyword("DLM"), "'")
+				streamAt = lineNo
+			}
+		}
+	}
+
+	if inStream && dlm != "" {
+		s.errorf(streamAt, "in-stream data is never terminated by delimiter %s", dlm)
+	}
+
+	return s
+}
+
+// parseStatement fills st from the record at index i and any continuation
+// records. It returns the index of the last record consumed.
+func (s *script) parseStatement(st *statement, i int) int {
+	line := s.lines[i]
+	pos := 2
+
+	if pos < len(line) && line[pos] != ' ' {
+		end := pos
+		for end < len(line) && line[end] != ' ' {
+			end++
+		}
+		st.name = line[pos:end]
+		pos = end
+	}
+
+	pos = skipBlanks(line, pos)
+	opStart := pos
+	for pos < len(line) && line[pos] != ' ' {
+		pos++
+	}
+	st.op = line[opStart:pos]
+	if opStart >= lastColumn {
+		st.op = ""
+	}
+
+	if st.op == "" {
+		s.errorf(i+1, "missing operation field")
+		st.lastLine = i + 1
+		return i
+	}
+	if !operations[st.op] {
+		s.errorf(i+1, "unknown operation %q", st.op)
+	}
+
+	if st.op == "IF" {
+		return s.parseIf(st, i, pos)
+	}
+
+	var ops strings.Builder
+	quoted := false
+	pos = skipBlanks(line, pos)
+
+	for {
+		lineNo := i + 1
+		st.segments = append(st.segments, segment{offset: ops.Len(), line: lineNo, col: pos})
+
+		end := pos
+		for end < len(line) && end < lastColumn {
+			c := line[end]
+			if !quoted && c == ' ' {
+				break
+			}
+			if c == '\'' {
+				quoted = !quoted
+			}
+			end++
+		}
+		if end == lastColumn && !quoted && len(line) > lastColumn && line[lastColumn] != ' ' {
+			s.errorf(lineNo, "operand extends past column 71")
+		}
+		if pos < end {
+			ops.WriteString(line[pos:end])
+		}
+		st.lastLine = lineNo
+
+		if quoted && end < lastColumn {
+			s.errorf(lineNo, "unbalanced quotes")
+			break
+		}
+		continued := quoted || strings.HasSuffix(ops.String(), ",")
+		if !continued {
+			break
+		}
+
+		// Find the continuation record, skipping comment statements
+		next := i + 1
+		for next < len(s.lines) && strings.HasPrefix(s.lines[next], "//*") {
+			next++
+		}
+		if next >= len(s.lines) || !strings.HasPrefix(s.lines[next], "//") || isNull(s.lines[next]) {
+			s.errorf(lineNo, "expected continuation after trailing comma")
+			break
+		}
+
+		cont := s.lines[next]
+		if len(cont) > 2 && cont[2] != ' ' {
+			s.errorf(next+1, "continuation must not have a name field")
+			break
+		}
+		i = next
+		line = cont
+		pos = skipBlanks(cont, 2)
+		if quoted {
+			if pos != maxContinueStart-1 {
+				s.errorf(next+1, "continued quoted string must resume in column 16")
+			}
+		} else if pos >= maxContinueStart {
+			s.errorf(next+1, "continuation must start between columns 4 and 16")
+		}
+	}
+
+	st.operands = ops.String()
+	if quoted {
+		st.broken = true
+		return i
+	}
+	if depth := parenDepth(st.operands); depth != 0 {
+		s.errorf(st.line, "unbalanced parentheses")
+		st.broken = true
+	} else {
+		st.params = splitParams(st.operands)
+	}
+	return i
+}
+
+// parseIf handles IF/THEN, whose expression may contain blanks.
+func (s *script) parseIf(st *statement, i, pos int) int {
+	var expr strings.Builder
+	for {
+		line := s.lines[i]
+		if len(line) > lastColumn {
+			line = line[:lastColumn]
+		}
+		if pos < len(line) {
+			expr.WriteString(" " + strings.TrimSpace(line[pos:]))
+		}
+		st.lastLine = i + 1
+
+		fields := strings.Fields(expr.String())
+		if len(fields) > 0 && fields[len(fields)-1] == "THEN" {
+			break
+		}
+		if i+1 >= len(s.lines) || !strings.HasPrefix(s.lines[i+1], "// ") {
+			s.errorf(st.line, "IF statement without THEN")
+			break
+		}
+		i++
+		pos = 2
+	}
+
+	st.operands = strings.TrimSpace(expr.String())
+	if parenDepth(st.operands) != 0 {
+		s.errorf(st.line, "unbalanced parentheses")
+	}
+	return i
+}
+
+func splitParams(ops string) []param {
+	var params []param
+	depth := 0
+	quoted := false
+	start := 0
+
+	flush := func(end int) {
+		raw := ops[start:end]
+		p := param{value: raw, offset: start}
+		if eq := topLevelEquals(raw); eq != -1 {
+			p.keyword = strings.ToUpper(raw[:eq])
+			p.value = raw[eq+1:]
+			p.offset = start + eq + 1
+		}
+		params = append(params, p)
+	}
+
+	for i := 0; i < len(ops); i++ {
+		switch c := ops[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			flush(i)
+			start = i + 1
+		}
+	}
+	if start < len(ops) || len(params) > 0 {
+		flush(len(ops))
+	}
+	return params
+}
+
+func topLevelEquals(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '=':
+			return i
+		case '(', '\'', '&':
+			return -1
+		}
+	}
+	return -1
+}
+
+func parenDepth(s string) int {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return depth
+			}
+		}
+	}
+	return depth
+}
+
+func skipBlanks(s string, pos int) int {
+	for pos < len(s) && s[pos] == ' ' {
+		pos++
+	}
+	return pos
+}
+
+func isDelimiter(line string) bool {
+	return strings.HasPrefix(line, "/*") && (len(line) == 2 || line[2] == ' ')
+}
+
+func isNull(line string) bool {
+	return strings.HasPrefix(line, "//") && strings.TrimSpace(line[2:]) == "" && !strings.HasPrefix(line, "//*")
+}
+
+// positional returns the first positional parameter, upper-cased.
+func (st *statement) positional() string {
+	if len(st.params) == 0 || st.params[0].keyword != "" {
+		return ""
+	}
+	return strings.ToUpper(st.params[0].value)
+}
+
+// keyword returns the value of a keyword parameter, or "" if absent.
+func (st *statement) keyword(name string) string {
+	if p := st.param(name); p != nil {
+		return p.value
+	}
+	return ""
+}
+
+func (st *statement) param(name string) *param {
+	for i := range st.params {
+		if st.params[i].keyword == name {
+			return &st.params[i]
+		}
+	}
+	return nil
+}
+
+// position maps an offset in the joined operand text to a line and column.
+func (st *statement) position(offset int) (line, col int) {
+	seg := st.segments[0]
+	for _, s := range st.segments {
+		if s.offset > offset {
+			break
+		}
+		seg = s
+	}
+	return seg.line, seg.col + offset - seg.offset
+}
+
+// operandEnd returns the column right after the operands on the last record.
+func (st *statement) operandEnd() int {
+	last := st.segments[len(st.segments)-1]
+	return last.col + len(st.operands) - last.offset
+}
+
+// segmentEnd returns the column right after the operands on the given line.
+func (st *statement) segmentEnd(line int) int {
+	for i, seg := range st.segments {
+		if seg.line != line {
+			continue
+		}
+		next := len(st.operands)
+		if i+1 < len(st.segments) {
+			next = st.segments[i+1].offset
+		}
+		return seg.col + next - seg.offset
+	}
+	return 0
+}
@@ -0,0 +1,156 @@
+package jcl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StepNames returns the names of the job-level EXEC statements, skipping
+// steps of in-stream procedures.
+func StepNames(src []byte) []string {
+	var names []string
+	for _, st := range jobSteps(parse(src)) {
+		names = append(names, st.name)
+	}
+	return names
+}
+
+// jobSteps returns the job-level EXEC statements.
+func jobSteps(s *script) []*statement {
+	var steps []*statement
+	inProc := false
+	for _, st := range s.statements {
+		switch st.op {
+		case "PROC":
+			inProc = true
+		case "PEND":
+			inProc = false
+		case "EXEC":
+			if !inProc {
+				steps = append(steps, st)
+			}
+		}
+	}
+	return steps
+}
+
+// CommentSteps turns every job step before step into comments, including
+// their DD statements and in-stream data, so the job starts at step. SET,
+// JCLLIB and OUTPUT statements and in-stream procedures are kept.
+//
+// Later statements that still refer to a commented step, for example in COND
+// or IF, are returned as warnings since JES will reject or ignore them.
+func CommentSteps(src []byte, step string) ([]byte, []string, error) {
+	step = strings.ToUpper(step)
+	s := parse(src)
+
+	steps := jobSteps(s)
+	target := -1
+	for i, st := range steps {
+		if st.name == step {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		return nil, nil, fmt.Errorf("step %s not found in the JCL", step)
+	}
+	if target == 0 {
+		return src, nil, nil
+	}
+
+	from, to := steps[0].line, steps[target].line // lines [from, to) are commented
+	keep := make(map[int]bool)
+	inProc := false
+	depth := 0
+	var removed []string
+	for _, st := range s.statements {
+		if st.line < from || st.line >= to {
+			continue
+		}
+		switch {
+		case st.op == "PROC":
+			inProc = true
+		case st.op == "PEND":
+			inProc = false
+		case st.op == "IF":
+			depth++
+		case st.op == "ENDIF":
+			depth--
+		}
+		if inProc || st.op == "PROC" || st.op == "PEND" || st.op == "SET" || st.op == "JCLLIB" || st.op == "OUTPUT" {
+			for l := st.line; l <= st.lastLine; l++ {
+				keep[l] = true
+			}
+		}
+		if st.op == "EXEC" && !inProc {
+			removed = append(removed, st.name)
+		}
+	}
+	if depth != 0 {
+		return nil, nil, fmt.Errorf("step %s is inside an IF/THEN construct that starts before it; use RESTART= instead", step)
+	}
+
+	lines := append([]string(nil), s.lines...)
+	for i := from - 1; i < to-1; i++ {
+		if keep[i+1] {
+			continue
+		}
+		lines[i] = commentLine(lines[i])
+	}
+
+	var warnings []string
+	for _, st := range s.statements {
+		if st.line < to {
+			continue
+		}
+		for _, name := range removed {
+			if refersTo(st.operands, name) {
+				warnings = append(warnings, fmt.Sprintf("line %d: %s refers to commented step %s", st.line, st.op, name))
+			}
+		}
+	}
+
+	return joinLines(lines, src), warnings, nil
+}
+
+// commentLine turns a JCL record or a line of in-stream data into a comment.
+func commentLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "//*"):
+		return line
+	case strings.HasPrefix(line, "//"):
+		return "//*" + line[2:]
+	case isDelimiter(line):
+		return "//*" + line[2:]
+	case strings.HasPrefix(line, "/*"):
+		// JES2 control statements apply to the whole job
+		return line
+	}
+	// Data shifted past the end of the record is dropped, so that the
+	// comment still fits in 80 bytes
+	line = "//* " + line
+	if len(line) > recordLength {
+		line = line[:recordLength]
+	}
+	return line
+}
+
+// refersTo reports whether operands name step as in STEP1.RC, (0,NE,STEP1)
+// or *.STEP1.DD.
+func refersTo(operands, step string) bool {
+	for i := 0; i < len(operands); {
+		j := strings.Index(operands[i:], step)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(step)
+		before := start == 0 || !isNameChar(operands[start-1], false)
+		after := end == len(operands) || !isNameChar(operands[end], false)
+		if before && after {
+			return true
+		}
+		i = end
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package jcl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const restartJCL = `//PAYJOB   JOB CLASS=A
+//         SET HLQ=PAY
+//MYPROC   PROC
+//P1       EXEC PGM=IEFBR14
+//         PEND
+//STEP1    EXEC PGM=SORT
+//SYSIN    DD *
+ SORT FIELDS=(1,8,CH,A)
+/*
+//SORTOUT  DD DSN=&HLQ..SORTED,DISP=SHR
+//STEP2    EXEC MYPROC
+//STEP3    EXEC PGM=REPORT,COND=(4,LT,STEP2)
+//IN       DD DSN=*.STEP1.SORTOUT,DISP=SHR
+`
+
+func TestStepNames(t *testing.T) {
+	want := []string{"STEP1", "STEP2", "STEP3"}
+	if got := StepNames([]byte(restartJCL)); !reflect.DeepEqual(got, want) {
+		t.Errorf("StepNames() = %v, want %v", got, want)
+	}
+}
+
+func TestCommentSteps(t *testing.T) {
+	got, warnings, err := CommentSteps([]byte(restartJCL), "step3")
+	if err != nil {
+		t.Fatalf("CommentSteps error: %v", err)
+	}
+
+	want := `//PAYJOB   JOB CLASS=A
+//         SET HLQ=PAY
+//MYPROC   PROC
+//P1       EXEC PGM=IEFBR14
+//         PEND
+//*STEP1    EXEC PGM=SORT
+//*SYSIN    DD *
+//*  SORT FIELDS=(1,8,CH,A)
+//*
+//*SORTOUT  DD DSN=&HLQ..SORTED,DISP=SHR
+//*STEP2    EXEC MYPROC
+//STEP3    EXEC PGM=REPORT,COND=(4,LT,STEP2)
+//IN       DD DSN=*.STEP1.SORTOUT,DISP=SHR
+`
+	if string(got) != want {
+		t.Errorf("CommentSteps() =\n%s\nwant\n%s", got, want)
+	}
+	if diags := Lint(got); HasErrors(diags) {
+		t.Errorf("rewritten JCL does not lint: %+v", diags)
+	}
+
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "STEP2") || !strings.Contains(warnings[1], "STEP1") {
+		t.Errorf("warnings = %v, want references to STEP2 and STEP1", warnings)
+	}
+}
+
+func TestCommentStepsErrors(t *testing.T) {
+	if _, _, err := CommentSteps([]byte(restartJCL), "P1"); err == nil {
+		t.Error("steps of in-stream procedures cannot be restart points")
+	}
+
+	src := "//J JOB\n//S1 EXEC PGM=A\n// IF (S1.RC = 0) THEN\n//S2 EXEC PGM=B\n// ENDIF\n"
+	if _, _, err := CommentSteps([]byte(src), "S2"); err == nil || !strings.Contains(err.Error(), "IF/THEN") {
+		t.Errorf("error = %v, want IF/THEN error", err)
+	}
+
+	got, _, err := CommentSteps([]byte(src), "S1")
+	if err != nil || string(got) != src {
+		t.Errorf("restarting from the first step should not change the JCL, got %q, %v", got, err)
+	}
+}
+
+func TestCommentLine(t *testing.T) {
+	data80 := " SORT FIELDS=(1,8,CH,A)" + strings.Repeat(" ", 49) + "00000100"
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"//STEP1    EXEC PGM=SORT", "//*STEP1    EXEC PGM=SORT"},
+		{"//* already a comment", "//* already a comment"},
+		{"/*", "//*"},
+		{"/*JOBPARM SYSAFF=*", "/*JOBPARM SYSAFF=*"},
+		{" SORT FIELDS=(1,8,CH,A)", "//*  SORT FIELDS=(1,8,CH,A)"},
+		{data80, "//* " + data80[:76]},
+	}
+	for _, tt := range tests {
+		if got := commentLine(tt.line); got != tt.want {
+			t.Errorf("commentLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
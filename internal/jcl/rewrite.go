@@ -0,0 +1,120 @@
+package jcl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetJobParameter sets KEYWORD=VALUE on the first JOB statement, replacing an
+// existing value or appending the parameter, on a new continuation record
+// when it does not fit before column 72.
+func SetJobParameter(src []byte, keyword, value string) ([]byte, error) {
+	s := parse(src)
+	job := s.job()
+	if job == nil {
+		return nil, fmt.Errorf("no JOB statement found")
+	}
+
+	keyword = strings.ToUpper(keyword)
+	lines := append([]string(nil), s.lines...)
+
+	if p := job.param(keyword); p != nil {
+		line, col := job.position(p.offset)
+		old := lines[line-1]
+		end := col + len(p.value)
+		if end > job.segmentEnd(line) {
+			return nil, fmt.Errorf("cannot rewrite %s: value is continued across records", keyword)
+		}
+		opEnd := job.segmentEnd(line) + len(value) - len(p.value)
+		if opEnd > lastColumn {
+			return nil, fmt.Errorf("cannot rewrite %s: value would extend past column 71", keyword)
+		}
+		updated := old[:col] + value + old[end:]
+		if len(strings.TrimRight(updated, " ")) > lastColumn {
+			// Drop comments and sequence numbers that would shift past column 71
+			updated = updated[:opEnd]
+		}
+		lines[line-1] = updated
+		return joinLines(lines, src), nil
+	}
+
+	idx := job.lastLine - 1
+	last := lines[idx]
+	end := len(last)
+	if len(job.segments) > 0 {
+		end = job.operandEnd()
+	}
+	if end > len(last) {
+		end = len(last)
+	}
+
+	if len(job.params) == 0 {
+		// JOB statement without operands: "//NAME JOB" becomes "//NAME JOB KEY=VAL"
+		head := strings.TrimRight(last[:min(len(last), lastColumn)], " ")
+		candidate := head + " " + keyword + "=" + value
+		if len(candidate) <= lastColumn {
+			lines[idx] = candidate
+			return joinLines(lines, src), nil
+		}
+		return nil, fmt.Errorf("cannot add %s: JOB statement is too long", keyword)
+	}
+
+	inline := last[:end] + "," + keyword + "=" + value
+	if strings.TrimSpace(last[end:]) == "" && len(inline) <= lastColumn {
+		lines[idx] = inline
+		return joinLines(lines, src), nil
+	}
+
+	if end+1 > lastColumn {
+		return nil, fmt.Errorf("cannot add %s: no room for a continuation comma", keyword)
+	}
+	cont := "//" + strings.Repeat(" ", maxContinueStart-3) + keyword + "=" + value
+	lines[idx] = continueRecord(last, end)
+	lines = append(lines[:idx+1], append([]string{cont}, lines[idx+1:]...)...)
+	return joinLines(lines, src), nil
+}
+
+// continueRecord adds the continuation comma after the operands ending at
+// end, keeping the comment field: the comma takes the first of two blanks,
+// or shifts a comment that follows a single blank.
+func continueRecord(line string, end int) string {
+	rest := line[end:]
+	if strings.TrimSpace(rest) == "" {
+		return line[:end] + ","
+	}
+	if strings.HasPrefix(rest, "  ") {
+		return line[:end] + "," + rest[1:]
+	}
+	updated := line[:end] + "," + rest
+	if len(strings.TrimRight(updated, " ")) > lastColumn {
+		// Drop what shifted past column 71
+		updated = strings.TrimRight(updated[:lastColumn], " ")
+	}
+	return updated
+}
+
+// JobName returns the name on the first JOB statement, or "" if there is none.
+func JobName(src []byte) string {
+	if job := parse(src).job(); job != nil {
+		return job.name
+	}
+	return ""
+}
+
+// job returns the first JOB statement.
+func (s *script) job() *statement {
+	for _, st := range s.statements {
+		if st.op == "JOB" {
+			return st
+		}
+	}
+	return nil
+}
+
+func joinLines(lines []string, orig []byte) []byte {
+	out := strings.Join(lines, "\n")
+	if strings.HasSuffix(string(orig), "\n") {
+		out += "\n"
+	}
+	return []byte(out)
+}
@@ -0,0 +1,86 @@
+package jcl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetJobParameter(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "append inline",
+			src:  "//J JOB CLASS=A\n//S EXEC PGM=X\n",
+			want: "//J JOB CLASS=A,TYPRUN=SCAN\n//S EXEC PGM=X\n",
+		},
+		{
+			name: "append to continued statement",
+			src:  "//J JOB CLASS=A,\n//         MSGCLASS=H\n//S EXEC PGM=X\n",
+			want: "//J JOB CLASS=A,\n//         MSGCLASS=H,TYPRUN=SCAN\n//S EXEC PGM=X\n",
+		},
+		{
+			name: "replace existing value",
+			src:  "//J JOB CLASS=A,TYPRUN=HOLD,MSGCLASS=H\n",
+			want: "//J JOB CLASS=A,TYPRUN=SCAN,MSGCLASS=H\n",
+		},
+		{
+			name: "no operands",
+			src:  "//J JOB\n",
+			want: "//J JOB TYPRUN=SCAN\n",
+		},
+		{
+			name: "new record when line has a comment",
+			src:  "//J JOB CLASS=A  MY COMMENT\n",
+			want: "//J JOB CLASS=A, MY COMMENT\n//             TYPRUN=SCAN\n",
+		},
+		{
+			name: "comment after a single blank",
+			src:  "//PAYROLL JOB (ACCT),'J SMITH',CLASS=A NIGHTLY RUN\n//S EXEC PGM=X\n",
+			want: "//PAYROLL JOB (ACCT),'J SMITH',CLASS=A, NIGHTLY RUN\n//             TYPRUN=SCAN\n//S EXEC PGM=X\n",
+		},
+		{
+			name: "comment up to column 71",
+			src:  "//J JOB CLASS=A " + strings.Repeat("C", 55) + "\n",
+			want: "//J JOB CLASS=A, " + strings.Repeat("C", 54) + "\n//             TYPRUN=SCAN\n",
+		},
+		{
+			name: "new record when line is full",
+			src:  "//J JOB (ACCOUNTING),'A VERY LONG PROGRAMMER NAME',CLASS=A,MSGCLASS=H\n",
+			want: "//J JOB (ACCOUNTING),'A VERY LONG PROGRAMMER NAME',CLASS=A,MSGCLASS=H,\n//             TYPRUN=SCAN\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetJobParameter([]byte(tt.src), "TYPRUN", "SCAN")
+			if err != nil {
+				t.Fatalf("SetJobParameter error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SetJobParameter() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if diags := Lint(got); HasErrors(diags) {
+				t.Errorf("rewritten JCL does not lint: %+v", diags)
+			}
+		})
+	}
+}
+
+func TestSetJobParameterNoJob(t *testing.T) {
+	_, err := SetJobParameter([]byte("//S EXEC PGM=X\n"), "TYPRUN", "SCAN")
+	if err == nil || !strings.Contains(err.Error(), "no JOB") {
+		t.Errorf("error = %v, want no JOB statement", err)
+	}
+}
+
+func TestJobName(t *testing.T) {
+	if got := JobName([]byte("//* header\n//PAYROLL1 JOB (ACCT),CLASS=A\n//S EXEC PGM=X\n")); got != "PAYROLL1" {
+		t.Errorf("JobName() = %q, want PAYROLL1", got)
+	}
+	if got := JobName([]byte("//S EXEC PGM=X\n")); got != "" {
+		t.Errorf("JobName() = %q, want empty", got)
+	}
+}
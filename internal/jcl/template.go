@@ -0,0 +1,183 @@
+package jcl
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const suffixChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Builtins returns the variables every template gets for free.
+// SUFFIX is a random alphanumeric character, handy for unique job names.
+func Builtins(user, hlq string) map[string]string {
+	return map[string]string{
+		"USER":   strings.ToUpper(user),
+		"HLQ":    strings.ToUpper(hlq),
+		"SUFFIX": string(suffixChars[rand.IntN(len(suffixChars))]),
+	}
+}
+
+// ParseVar splits a NAME=VALUE command line assignment.
+func ParseVar(s string) (name, value string, err error) {
+	name, value, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || !validName(name) {
+		return "", "", fmt.Errorf("invalid variable %q (expected NAME=VALUE)", s)
+	}
+	return strings.ToUpper(name), value, nil
+}
+
+// LoadVars reads a flat YAML mapping of variable names to values.
+func LoadVars(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read vars file: %w", err)
+	}
+
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid vars file %s: %w", path, err)
+	}
+
+	vars := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if !validName(name) {
+			return nil, fmt.Errorf("invalid variable name %q in %s", name, path)
+		}
+		vars[strings.ToUpper(name)] = value
+	}
+	return vars, nil
+}
+
+// Substitute replaces ${NAME} and &NAME placeholders.
+//
+// ${NAME} is looked up in vars, then in builtins. &NAME is only replaced from
+// vars, so JES system symbols and SET symbols are left alone unless the user
+// overrides them explicitly. As in JCL, a period right after &NAME is consumed
+// as a delimiter (&HLQ..LOAD becomes MYHLQ.LOAD), and && temporary dataset
+// names are never touched. Placeholders that cannot be resolved stay in the
+// output and are returned as unresolved, sorted by first appearance.
+func Substitute(src []byte, vars, builtins map[string]string) ([]byte, []string) {
+	var out strings.Builder
+	out.Grow(len(src))
+
+	var unresolved []string
+	seen := make(map[string]bool)
+	miss := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			unresolved = append(unresolved, name)
+		}
+	}
+
+	s := string(src)
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			// A placeholder never spans records
+			end := strings.IndexAny(s[i+2:], "}\n")
+			if end == -1 || s[i+2+end] == '\n' {
+				out.WriteString("${")
+				i += 2
+				continue
+			}
+			name := strings.ToUpper(s[i+2 : i+2+end])
+			if v, ok := lookup(name, vars, builtins); ok && validName(name) {
+				out.WriteString(v)
+			} else {
+				miss(name)
+				out.WriteString(s[i : i+3+end])
+			}
+			i += end + 3
+
+		case s[i] == '&' && i+1 < len(s) && s[i+1] == '&':
+			// &&TEMP dataset name
+			n := 2 + nameLen(s[i+2:])
+			out.WriteString(s[i : i+n])
+			i += n
+
+		case s[i] == '&':
+			n := nameLen(s[i+1:])
+			if n == 0 {
+				out.WriteByte('&')
+				i++
+				continue
+			}
+			name := strings.ToUpper(s[i+1 : i+1+n])
+			v, ok := vars[name]
+			if !ok {
+				out.WriteString(s[i : i+1+n])
+				i += 1 + n
+				continue
+			}
+			out.WriteString(v)
+			i += 1 + n
+			if i < len(s) && s[i] == '.' {
+				i++
+			}
+
+		default:
+			out.WriteByte(s[i])
+			i++
+		}
+	}
+
+	return []byte(out.String()), unresolved
+}
+
+// LongLines returns the 1-based numbers of the lines that extend past
+// column 71 in out but not in src, that is records a substitution made too
+// long for JCL. Lines that were long before, such as ones with sequence
+// numbers, are not reported.
+func LongLines(src, out []byte) []int {
+	before := strings.Split(string(src), "\n")
+	after := strings.Split(string(out), "\n")
+	var long []int
+	for i, line := range after {
+		if i < len(before) && recordLen(before[i]) > lastColumn {
+			continue
+		}
+		if recordLen(line) > lastColumn {
+			long = append(long, i+1)
+		}
+	}
+	return long
+}
+
+func recordLen(line string) int {
+	return len(strings.TrimRight(line, " \r"))
+}
+
+func lookup(name string, vars, builtins map[string]string) (string, bool) {
+	if v, ok := vars[name]; ok {
+		return v, true
+	}
+	v, ok := builtins[name]
+	return v, ok
+}
+
+func nameLen(s string) int {
+	n := 0
+	for n < len(s) && isNameChar(s[n], n == 0) {
+		n++
+	}
+	return n
+}
+
+func validName(name string) bool {
+	return name != "" && nameLen(name) == len(name)
+}
+
+func isNameChar(c byte, first bool) bool {
+	switch {
+	case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c == '@', c == '#', c == '$', c == '_':
+		return true
+	case c >= '0' && c <= '9':
+		return !first
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package jcl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSubstitute(t *testing.T) {
+	vars := map[string]string{"HLQ": "DEV1", "PGM": "IEFBR14"}
+	builtins := map[string]string{"USER": "FALZONE", "HLQ": "FALZONE", "SUFFIX": "X"}
+
+	tests := []struct {
+		name           string
+		src            string
+		want           string
+		wantUnresolved []string
+	}{
+		{
+			name: "brace placeholder",
+			src:  "//${USER}${SUFFIX} JOB",
+			want: "//FALZONEX JOB",
+		},
+		{
+			name: "user var wins over builtin",
+			src:  "DSN=${HLQ}.LOAD",
+			want: "DSN=DEV1.LOAD",
+		},
+		{
+			name: "ampersand with delimiter period",
+			src:  "DSN=&HLQ..LOAD",
+			want: "DSN=DEV1.LOAD",
+		},
+		{
+			name: "ampersand without period",
+			src:  "PGM=&PGM,REGION=0M",
+			want: "PGM=IEFBR14,REGION=0M",
+		},
+		{
+			name: "lower case name",
+			src:  "${hlq}",
+			want: "DEV1",
+		},
+		{
+			name: "builtin not used for ampersand",
+			src:  "//STEP1 EXEC PGM=X,PARM='&USER'",
+			want: "//STEP1 EXEC PGM=X,PARM='&USER'",
+		},
+		{
+			name: "system symbol kept",
+			src:  "NOTIFY=&SYSUID",
+			want: "NOTIFY=&SYSUID",
+		},
+		{
+			name: "temporary dataset kept",
+			src:  "DSN=&&PGM,DISP=(NEW,PASS)",
+			want: "DSN=&&PGM,DISP=(NEW,PASS)",
+		},
+		{
+			name:           "undefined brace left as is",
+			src:            "${NOPE} ${NOPE}",
+			want:           "${NOPE} ${NOPE}",
+			wantUnresolved: []string{"NOPE"},
+		},
+		{
+			name: "unterminated brace",
+			src:  "A ${HLQ",
+			want: "A ${HLQ",
+		},
+		{
+			name: "unterminated brace stops at end of record",
+			src:  "//S1 EXEC PGM=${PGM\n//DD1 DD DSN=${HLQ}.DATA\n",
+			want: "//S1 EXEC PGM=${PGM\n//DD1 DD DSN=DEV1.DATA\n",
+		},
+		{
+			name: "lone ampersand",
+			src:  "A & B",
+			want: "A & B",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unresolved := Substitute([]byte(tt.src), vars, builtins)
+			if string(got) != tt.want {
+				t.Errorf("Substitute(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+			if !reflect.DeepEqual(unresolved, tt.wantUnresolved) {
+				t.Errorf("unresolved = %v, want %v", unresolved, tt.wantUnresolved)
+			}
+		})
+	}
+}
+
+func TestLongLines(t *testing.T) {
+	seq := "//STEP1    EXEC PGM=IEFBR14" + strings.Repeat(" ", 45) + "00010000"
+	src := "//MYJOB JOB\n//S1 EXEC PGM=X,PARM='${P}'\n" + seq + "\n"
+	out, _ := Substitute([]byte(src), map[string]string{"P": strings.Repeat("A", 60)}, nil)
+
+	if got := LongLines([]byte(src), out); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("LongLines() = %v, want [2]", got)
+	}
+	if got := LongLines([]byte(src), []byte(src)); got != nil {
+		t.Errorf("LongLines() without substitution = %v, want none", got)
+	}
+}
+
+func TestParseVar(t *testing.T) {
+	tests := []struct {
+		in        string
+		wantName  string
+		wantValue string
+		wantErr   bool
+	}{
+		{in: "HLQ=DEV1", wantName: "HLQ", wantValue: "DEV1"},
+		{in: "hlq=a=b", wantName: "HLQ", wantValue: "a=b"},
+		{in: "EMPTY=", wantName: "EMPTY", wantValue: ""},
+		{in: "NOVALUE", wantErr: true},
+		{in: "=X", wantErr: true},
+		{in: "1ABC=X", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			name, value, err := ParseVar(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVar(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if name != tt.wantName || value != tt.wantValue {
+				t.Errorf("ParseVar(%q) = %q, %q, want %q, %q", tt.in, name, value, tt.wantName, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestLoadVars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vars.yaml")
+	content := "hlq: DEV1\nREGION: 0M\nCOUNT: 10\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	vars, err := LoadVars(path)
+	if err != nil {
+		t.Fatalf("LoadVars error: %v", err)
+	}
+
+	want := map[string]string{"HLQ": "DEV1", "REGION": "0M", "COUNT": "10"}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("LoadVars = %v, want %v", vars, want)
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	b := Builtins("falzone", "dev1")
+	if b["USER"] != "FALZONE" || b["HLQ"] != "DEV1" {
+		t.Errorf("Builtins = %v", b)
+	}
+	if len(b["SUFFIX"]) != 1 || !strings.Contains(suffixChars, b["SUFFIX"]) {
+		t.Errorf("SUFFIX = %q, want one alphanumeric character", b["SUFFIX"])
+	}
+}
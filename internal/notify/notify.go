@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"zm/internal/config"
+	"zm/internal/connection"
+)
+
+// hookTimeout bounds how long a single hook may take.
+const hookTimeout = 30 * time.Second
+
+// Event is the job status passed to hooks. Webhooks receive it as JSON,
+// commands on stdin and as ZM_* environment variables.
+type Event struct {
+	JobID    string    `json:"jobid"`
+	JobName  string    `json:"jobname"`
+	Owner    string    `json:"owner"`
+	Status   string    `json:"status"`
+	RetCode  string    `json:"retcode"`
+	Failed   bool      `json:"failed"`
+	Profile  string    `json:"profile"`
+	Host     string    `json:"host"`
+	Finished time.Time `json:"finished"`
+}
+
+// NewEvent builds the event for a finished job.
+func NewEvent(profile string, host string, job *connection.JobStatus) Event {
+	finished := job.Ended
+	if finished.IsZero() {
+		finished = time.Now()
+	}
+	return Event{
+		JobID:    job.JobID,
+		JobName:  job.JobName,
+		Owner:    job.Owner,
+		Status:   job.Status,
+		RetCode:  job.RetCode,
+		Failed:   connection.MatchRetCode(job.RetCode, "failed"),
+		Profile:  profile,
+		Host:     host,
+		Finished: finished,
+	}
+}
+
+// Run runs every hook that applies to the event and returns the errors of
+// the hooks that failed. A failing hook does not stop the others.
+func Run(ctx context.Context, hooks []config.Hook, ev Event) []error {
+	var errs []error
+	for _, h := range hooks {
+		if !applies(h, ev) {
+			continue
+		}
+		if err := run(ctx, h, ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func applies(h config.Hook, ev Event) bool {
+	switch h.On {
+	case "success":
+		return !ev.Failed
+	case "failure":
+		return ev.Failed
+	}
+	return true
+}
+
+func run(ctx context.Context, h config.Hook, ev Event) error {
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+
+	switch {
+	case h.Command != "":
+		return runCommand(ctx, h.Command, ev)
+	case h.Desktop:
+		return desktop(ctx, ev)
+	case h.Webhook != "":
+		return postWebhook(ctx, h.Webhook, ev)
+	}
+	return nil
+}
+
+func runCommand(ctx context.Context, command string, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	cmd := shellCommand(ctx, command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"ZM_JOBID="+ev.JobID,
+		"ZM_JOBNAME="+ev.JobName,
+		"ZM_OWNER="+ev.Owner,
+		"ZM_STATUS="+ev.Status,
+		"ZM_RETCODE="+ev.RetCode,
+		fmt.Sprintf("ZM_FAILED=%t", ev.Failed),
+		"ZM_PROFILE="+ev.Profile,
+	)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("notify command %q failed: %w", command, err)
+	}
+	return nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+func desktop(ctx context.Context, ev Event) error {
+	title := fmt.Sprintf("%s %s", ev.JobName, ev.JobID)
+	body := fmt.Sprintf("Completed — %s", ev.RetCode)
+	if ev.RetCode == "" {
+		body = ev.Status
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", body, title)
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		urgency := "normal"
+		if ev.Failed {
+			urgency = "critical"
+		}
+		cmd = exec.CommandContext(ctx, "notify-send", "-u", urgency, title, body)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("desktop notification failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func postWebhook(ctx context.Context, url string, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("invalid webhook %s: %w", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "zm")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %s", url, resp.Status)
+	}
+	return nil
+}
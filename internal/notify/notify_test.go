@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zm/internal/config"
+	"zm/internal/connection"
+)
+
+func testEvent(rc string) Event {
+	return NewEvent("prod", "mainframe.example.com", &connection.JobStatus{
+		JobID: "JOB00001", JobName: "PAYROLL", Owner: "FALZONE", Status: "OUTPUT", RetCode: rc,
+	})
+}
+
+func TestWebhook(t *testing.T) {
+	var got Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	errs := Run(context.Background(), []config.Hook{{Webhook: srv.URL}}, testEvent("ABEND S0C7"))
+	if len(errs) != 0 {
+		t.Fatalf("Run errors: %v", errs)
+	}
+	if got.JobID != "JOB00001" || got.RetCode != "ABEND S0C7" || !got.Failed || got.Profile != "prod" {
+		t.Errorf("webhook received %+v", got)
+	}
+}
+
+func TestWebhookError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	errs := Run(context.Background(), []config.Hook{{Webhook: srv.URL}}, testEvent("CC 0000"))
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "500") {
+		t.Errorf("Run errors = %v, want a 500 error", errs)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	hooks := []config.Hook{
+		{Command: `echo "$ZM_JOBID $ZM_RETCODE $ZM_FAILED" > ` + out + `; cat >> ` + out},
+	}
+
+	if errs := Run(context.Background(), hooks, testEvent("CC 0000")); len(errs) != 0 {
+		t.Fatalf("Run errors: %v", errs)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, payload, _ := strings.Cut(string(data), "\n")
+	if first != "JOB00001 CC 0000 false" {
+		t.Errorf("environment = %q", first)
+	}
+	if !strings.Contains(payload, `"jobname":"PAYROLL"`) {
+		t.Errorf("stdin payload = %q", payload)
+	}
+}
+
+func TestOn(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	hooks := []config.Hook{
+		{Command: "echo success >> " + out, On: "success"},
+		{Command: "echo failure >> " + out, On: "failure"},
+		{Command: "echo always >> " + out},
+	}
+
+	Run(context.Background(), hooks, testEvent("CC 0012"))
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "failure\nalways\n" {
+		t.Errorf("hooks run = %q, want failure and always", data)
+	}
+}
@@ -0,0 +1,29 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+)
+
+// Match is a spool line matching a search pattern.
+type Match struct {
+	Line int // 1-based line number within the spool file
+	Text string
+}
+
+// Grep returns the lines of data that match re.
+func Grep(data []byte, re *regexp.Regexp) []Match {
+	var matches []Match
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := scanner.Text()
+		if re.MatchString(line) {
+			matches = append(matches, Match{Line: n, Text: line})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,52 @@
+package spool
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	data := []byte("IEF142I MYJOB STEP1 - STEP WAS EXECUTED - COND CODE 0000\n" +
+		"IGZ0035S There was an unsuccessful OPEN or CLOSE of file INFILE\n" +
+		"IEF472I MYJOB STEP2 - COMPLETION CODE - SYSTEM=000 USER=4038\n" +
+		"igz0035s lower case\n")
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []Match
+	}{
+		{
+			name:    "message id",
+			pattern: "IGZ0035S",
+			want:    []Match{{Line: 2, Text: "IGZ0035S There was an unsuccessful OPEN or CLOSE of file INFILE"}},
+		},
+		{
+			name:    "case insensitive",
+			pattern: "(?i)igz0035s",
+			want: []Match{
+				{Line: 2, Text: "IGZ0035S There was an unsuccessful OPEN or CLOSE of file INFILE"},
+				{Line: 4, Text: "igz0035s lower case"},
+			},
+		},
+		{
+			name:    "regexp",
+			pattern: `USER=\d{4}`,
+			want:    []Match{{Line: 3, Text: "IEF472I MYJOB STEP2 - COMPLETION CODE - SYSTEM=000 USER=4038"}},
+		},
+		{
+			name:    "no match",
+			pattern: "IEC141I",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Grep(data, regexp.MustCompile(tt.pattern))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Grep() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package spool
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Step is the outcome of one job step as reported in JESYSMSG.
+type Step struct {
+	Name     string `json:"name"`               // EXEC statement name
+	ProcStep string `json:"procstep,omitempty"` // step inside the procedure, if any
+	RetCode  string `json:"retcode"`            // CC 0000, ABEND S0C7, ABEND U4038, FLUSH
+}
+
+var (
+	// IEF142I MYJOB [PROCSTEP] STEP1 - STEP WAS EXECUTED - COND CODE 0004
+	executedRe = regexp.MustCompile(`IEF142I\s+\S+\s+(\S+)(?:\s+(\S+))?\s+-\s+STEP WAS EXECUTED\s+-\s+COND CODE\s+(\d+)`)
+	// IEF272I MYJOB [PROCSTEP] STEP2 - STEP WAS NOT EXECUTED.
+	flushedRe = regexp.MustCompile(`IEF272I\s+\S+\s+(\S+)(?:\s+(\S+))?\s+-\s+STEP WAS NOT EXECUTED`)
+	// IEF472I MYJOB [PROCSTEP] STEP3 - COMPLETION CODE - SYSTEM=0C7 USER=0000 REASON=00000007
+	abendRe = regexp.MustCompile(`IEF472I\s+\S+\s+(\S+)(?:\s+(\S+))?\s+-\s+COMPLETION CODE\s+-\s+SYSTEM=([0-9A-F]{3})\s+USER=(\d{4})`)
+)
+
+// ParseSteps extracts step results from JESYSMSG (or the whole job output).
+// Steps are returned in the order they appear.
+func ParseSteps(sysmsg []byte) []Step {
+	var steps []Step
+	for _, line := range strings.Split(string(sysmsg), "\n") {
+		var step Step
+		switch {
+		case executedRe.MatchString(line):
+			m := executedRe.FindStringSubmatch(line)
+			step = newStep(m[1], m[2])
+			step.RetCode = "CC " + m[3]
+		case flushedRe.MatchString(line):
+			m := flushedRe.FindStringSubmatch(line)
+			step = newStep(m[1], m[2])
+			step.RetCode = "FLUSH"
+		case abendRe.MatchString(line):
+			m := abendRe.FindStringSubmatch(line)
+			step = newStep(m[1], m[2])
+			if m[3] != "000" {
+				step.RetCode = "ABEND S" + m[3]
+			} else {
+				step.RetCode = "ABEND U" + m[4]
+			}
+		default:
+			continue
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// newStep orders the names the way IEF messages print them: the procedure
+// step, when present, comes before the EXEC statement name.
+func newStep(first, second string) Step {
+	if second == "" {
+		return Step{Name: first}
+	}
+	return Step{Name: second, ProcStep: first}
+}
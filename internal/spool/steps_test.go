@@ -0,0 +1,38 @@
+package spool
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSteps(t *testing.T) {
+	sysmsg := `ICH70001I FALZONE  LAST ACCESS AT 10:52:33 ON FRIDAY, JUNE 14, 2025
+IEF236I ALLOC. FOR MYJOB STEP1
+IEF142I MYJOB STEP1 - STEP WAS EXECUTED - COND CODE 0000
+IEF373I STEP/STEP1   /START 2025165.1052
+IEF142I MYJOB COBOL COMPILE - STEP WAS EXECUTED - COND CODE 0004
+IEF472I MYJOB RUN - COMPLETION CODE - SYSTEM=0C7 USER=0000 REASON=00000007
+IEF472I MYJOB APP - COMPLETION CODE - SYSTEM=000 USER=4038 REASON=00000000
+IEF272I MYJOB CLEANUP - STEP WAS NOT EXECUTED.
+IEF375I  JOB/MYJOB   /START 2025165.1052
+`
+
+	want := []Step{
+		{Name: "STEP1", RetCode: "CC 0000"},
+		{Name: "COMPILE", ProcStep: "COBOL", RetCode: "CC 0004"},
+		{Name: "RUN", RetCode: "ABEND S0C7"},
+		{Name: "APP", RetCode: "ABEND U4038"},
+		{Name: "CLEANUP", RetCode: "FLUSH"},
+	}
+
+	got := ParseSteps([]byte(sysmsg))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSteps() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseStepsEmpty(t *testing.T) {
+	if steps := ParseSteps(nil); len(steps) != 0 {
+		t.Errorf("expected no steps, got %+v", steps)
+	}
+}
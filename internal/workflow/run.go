@@ -0,0 +1,214 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"zm/internal/connection"
+)
+
+// State is the final state of a workflow job.
+type State string
+
+const (
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"  // ended above max_rc, abended, or could not be submitted or awaited
+	StateSkipped   State = "skipped" // its condition was not met
+)
+
+// Result is the outcome of one workflow job.
+type Result struct {
+	Name    string
+	JobID   string
+	State   State
+	RetCode string
+	Reason  string // why the job failed or was skipped
+	Start   time.Time
+	End     time.Time
+}
+
+// Runner executes a workflow.
+type Runner struct {
+	Conn connection.Connection
+
+	// Submit submits the JCL of a job and returns its job ID.
+	Submit func(job Job) (string, error)
+
+	// MaxParallel limits the number of jobs running at once. Zero means the
+	// workflow's max_parallel, or one job per ready dependency if neither is set.
+	MaxParallel int
+
+	// Completed, if not nil, is called when a submitted job reaches OUTPUT.
+	Completed func(job Job, status *connection.JobStatus)
+
+	// Logf, if not nil, is called on every submission and completion.
+	Logf func(format string, args ...any)
+}
+
+// Run submits every job once the jobs it needs are done and its condition
+// holds, and waits for all of them. Results are returned in workflow order.
+func (r *Runner) Run(ctx context.Context, wf *Workflow) []Result {
+	results := make([]Result, len(wf.Jobs))
+	done := make(map[string]chan struct{}, len(wf.Jobs))
+	index := make(map[string]int, len(wf.Jobs))
+	for i, j := range wf.Jobs {
+		done[j.Name] = make(chan struct{})
+		index[j.Name] = i
+	}
+
+	limit := r.MaxParallel
+	if limit == 0 {
+		limit = wf.MaxParallel
+	}
+	if limit == 0 {
+		limit = len(wf.Jobs)
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, job := range wf.Jobs {
+		wg.Add(1)
+		go func(i int, job Job) {
+			defer wg.Done()
+			defer close(done[job.Name])
+
+			needs := make([]Result, 0, len(job.Needs))
+			for _, need := range job.Needs {
+				<-done[need]
+				needs = append(needs, results[index[need]])
+			}
+
+			if ok, reason := conditionMet(job, needs); !ok {
+				results[i] = Result{Name: job.Name, State: StateSkipped, Reason: reason}
+				r.logf("[%s] skipped: %s", job.Name, reason)
+				return
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = Result{Name: job.Name, State: StateSkipped, Reason: ctx.Err().Error()}
+				return
+			}
+			defer func() { <-sem }()
+
+			results[i] = r.runJob(ctx, job)
+		}(i, job)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (r *Runner) runJob(ctx context.Context, job Job) Result {
+	res := Result{Name: job.Name, Start: time.Now()}
+	fail := func(reason string) Result {
+		res.State = StateFailed
+		res.Reason = reason
+		res.End = time.Now()
+		r.logf("[%s] failed: %s", job.Name, reason)
+		return res
+	}
+
+	if err := ctx.Err(); err != nil {
+		res.State = StateSkipped
+		res.Reason = err.Error()
+		return res
+	}
+
+	jobid, err := r.Submit(job)
+	if err != nil {
+		return fail(err.Error())
+	}
+	res.JobID = jobid
+	r.logf("[%s] submitted %s", job.Name, jobid)
+
+	waitCtx := ctx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	status, err := connection.WaitForJob(waitCtx, r.Conn, jobid, nil)
+	if err != nil {
+		return fail(err.Error())
+	}
+	res.RetCode = status.RetCode
+	res.End = time.Now()
+	if r.Completed != nil {
+		r.Completed(job, status)
+	}
+
+	if reason := checkRetCode(job, status.RetCode); reason != "" {
+		return fail(reason)
+	}
+	res.State = StateSucceeded
+	r.logf("[%s] %s completed — %s", job.Name, jobid, status.RetCode)
+	return res
+}
+
+// checkRetCode returns why rc is not acceptable for job, or "" if it is.
+func checkRetCode(job Job, rc string) string {
+	if rc == "" {
+		return "no return code"
+	}
+	if strings.Contains(rc, "ABEND") || strings.Contains(rc, "JCL ERROR") {
+		return rc
+	}
+	maxRC := defaultMaxRC
+	if job.MaxRC != nil {
+		maxRC = *job.MaxRC
+	}
+	if !connection.MatchRetCode(rc, fmt.Sprintf("<=%d", maxRC)) {
+		return fmt.Sprintf("%s exceeds max_rc %d", rc, maxRC)
+	}
+	return ""
+}
+
+// conditionMet evaluates the job's when condition against the jobs it needs.
+func conditionMet(job Job, needs []Result) (bool, string) {
+	when := strings.ToLower(job.When)
+
+	switch when {
+	case WhenAlways:
+		return true, ""
+
+	case WhenFailure:
+		for _, n := range needs {
+			if n.State == StateFailed {
+				return true, ""
+			}
+		}
+		return false, "no needed job failed"
+
+	case "", WhenSuccess:
+		for _, n := range needs {
+			if n.State != StateSucceeded {
+				return false, fmt.Sprintf("%s %s", n.Name, n.State)
+			}
+		}
+		return true, ""
+	}
+
+	// A return code expression must hold for every needed job that ran.
+	for _, n := range needs {
+		if n.JobID == "" || !connection.MatchRetCode(n.RetCode, job.When) {
+			rc := n.RetCode
+			if rc == "" {
+				rc = string(n.State)
+			}
+			return false, fmt.Sprintf("%s %s does not match %s", n.Name, rc, job.When)
+		}
+	}
+	return true, ""
+}
+
+func (r *Runner) logf(format string, args ...any) {
+	if r.Logf != nil {
+		r.Logf(format, args...)
+	}
+}
@@ -0,0 +1,103 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"zm/internal/connection"
+)
+
+// jobStub completes every job immediately with the return code configured
+// for its JCL source.
+type jobStub struct {
+	connection.Connection
+	mu     sync.Mutex
+	rcs    map[string]string
+	jobs   map[string]string
+	submit []string
+}
+
+func (s *jobStub) submitJob(job Job) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job.JCL == "BAD" {
+		return "", fmt.Errorf("dataset not found")
+	}
+	jobid := fmt.Sprintf("JOB%05d", len(s.jobs)+1)
+	s.jobs[jobid] = job.JCL
+	s.submit = append(s.submit, job.Name)
+	return jobid, nil
+}
+
+func (s *jobStub) GetJobStatus(jobid string) (*connection.JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &connection.JobStatus{JobID: jobid, Status: "OUTPUT", RetCode: s.rcs[s.jobs[jobid]]}, nil
+}
+
+func TestRun(t *testing.T) {
+	eight := 8
+	wf := &Workflow{Jobs: []Job{
+		{Name: "extract", JCL: "EXTRACT"},
+		{Name: "load", JCL: "LOAD", Needs: []string{"extract"}},
+		{Name: "report", JCL: "REPORT", Needs: []string{"load"}},
+		{Name: "cleanup", JCL: "CLEANUP", Needs: []string{"load"}, When: WhenFailure},
+		{Name: "audit", JCL: "AUDIT", Needs: []string{"extract"}, MaxRC: &eight},
+		{Name: "notify", JCL: "NOTIFY", Needs: []string{"report", "cleanup"}, When: WhenAlways},
+		{Name: "lenient", JCL: "REPORT", Needs: []string{"load"}, When: "<=12"},
+		{Name: "broken", JCL: "BAD", Needs: []string{"audit"}},
+	}}
+	conn := &jobStub{
+		jobs: map[string]string{},
+		rcs: map[string]string{
+			"EXTRACT": "CC 0000",
+			"LOAD":    "CC 0012",
+			"CLEANUP": "CC 0000",
+			"AUDIT":   "CC 0008",
+			"NOTIFY":  "CC 0000",
+			"REPORT":  "CC 0000",
+		},
+	}
+
+	runner := &Runner{Conn: conn, Submit: conn.submitJob, MaxParallel: 2}
+	results := runner.Run(context.Background(), wf)
+
+	want := map[string]State{
+		"extract": StateSucceeded,
+		"load":    StateFailed,
+		"report":  StateSkipped,
+		"cleanup": StateSucceeded,
+		"audit":   StateSucceeded,
+		"notify":  StateSucceeded,
+		"lenient": StateSucceeded,
+		"broken":  StateFailed,
+	}
+	for i, r := range results {
+		if r.Name != wf.Jobs[i].Name {
+			t.Errorf("result %d is %s, want %s", i, r.Name, wf.Jobs[i].Name)
+		}
+		if r.State != want[r.Name] {
+			t.Errorf("%s: state = %s (%s), want %s", r.Name, r.State, r.Reason, want[r.Name])
+		}
+	}
+	if conn.submit[0] != "extract" {
+		t.Errorf("extract should be submitted first, got %v", conn.submit)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	wf := &Workflow{Jobs: []Job{{Name: "a", JCL: "A"}, {Name: "b", JCL: "B", Needs: []string{"a"}}}}
+	conn := &jobStub{jobs: map[string]string{}, rcs: map[string]string{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runner := &Runner{Conn: conn, Submit: conn.submitJob}
+	for _, r := range runner.Run(ctx, wf) {
+		if r.State != StateSkipped || r.JobID != "" {
+			t.Errorf("%s: state = %s, job %q; want skipped and not submitted", r.Name, r.State, r.JobID)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zm/internal/connection"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Conditions a job can put on the outcome of the jobs it needs.
+const (
+	WhenSuccess = "success" // every needed job succeeded (default)
+	WhenFailure = "failure" // at least one needed job failed
+	WhenAlways  = "always"  // run once the needed jobs are done, whatever the outcome
+)
+
+// Workflow is a set of jobs with dependencies, read from a YAML file:
+//
+//	name: nightly test batch
+//	max_parallel: 2
+//	vars:
+//	  ENV: TEST
+//	jobs:
+//	  - name: extract
+//	    jcl: MY.JCL(EXTRACT)
+//	  - name: load
+//	    jcl: jcl/load.jcl
+//	    needs: [extract]
+//	  - name: report
+//	    jcl: MY.JCL(REPORT)
+//	    needs: [extract]
+//	    when: "<=8"
+//	  - name: cleanup
+//	    jcl: MY.JCL(CLEANUP)
+//	    needs: [load]
+//	    when: failure
+type Workflow struct {
+	Name        string            `yaml:"name"`
+	MaxParallel int               `yaml:"max_parallel"`
+	Vars        map[string]string `yaml:"vars"`
+	Jobs        []Job             `yaml:"jobs"`
+}
+
+// Job is one JCL submission in a workflow.
+type Job struct {
+	Name    string            `yaml:"name"`
+	JCL     string            `yaml:"jcl"`   // DS(MEMBER), local file or USS path
+	Needs   []string          `yaml:"needs"` // jobs that must finish first
+	When    string            `yaml:"when"`  // success, failure, always or a return code expression
+	MaxRC   *int              `yaml:"max_rc"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Vars    map[string]string `yaml:"vars"` // merged over the workflow vars
+}
+
+// defaultMaxRC is the highest condition code a job may end with and still
+// count as successful.
+const defaultMaxRC = 4
+
+// Load reads and validates a workflow file. Local JCL paths are resolved
+// relative to the directory of the file.
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read workflow: %w", err)
+	}
+
+	var wf Workflow
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("invalid workflow %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range wf.Jobs {
+		j := &wf.Jobs[i]
+		if j.JCL != "" && !filepath.IsAbs(j.JCL) && !strings.Contains(j.JCL, "(") {
+			if local := filepath.Join(dir, j.JCL); fileExists(local) {
+				j.JCL = local
+			}
+		}
+	}
+
+	if err := wf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid workflow %s: %w", path, err)
+	}
+	return &wf, nil
+}
+
+// Validate checks job names, conditions and dependencies, and rejects cycles.
+func (wf *Workflow) Validate() error {
+	if len(wf.Jobs) == 0 {
+		return fmt.Errorf("no jobs defined")
+	}
+	if wf.MaxParallel < 0 {
+		return fmt.Errorf("invalid max_parallel %d", wf.MaxParallel)
+	}
+
+	index := make(map[string]int, len(wf.Jobs))
+	for i, j := range wf.Jobs {
+		if j.Name == "" {
+			return fmt.Errorf("job %d has no name", i+1)
+		}
+		if _, dup := index[j.Name]; dup {
+			return fmt.Errorf("duplicate job name %q", j.Name)
+		}
+		if j.JCL == "" {
+			return fmt.Errorf("job %q has no jcl", j.Name)
+		}
+		if err := validateWhen(j.When); err != nil {
+			return fmt.Errorf("job %q: %w", j.Name, err)
+		}
+		index[j.Name] = i
+	}
+
+	for _, j := range wf.Jobs {
+		for _, need := range j.Needs {
+			if _, ok := index[need]; !ok {
+				return fmt.Errorf("job %q needs unknown job %q", j.Name, need)
+			}
+		}
+	}
+
+	if cycle := wf.findCycle(index); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+func validateWhen(when string) error {
+	switch strings.ToLower(when) {
+	case "", WhenSuccess, WhenFailure, WhenAlways:
+		return nil
+	}
+	f := connection.JobFilter{RetCode: when}
+	return f.Validate()
+}
+
+// findCycle returns the jobs forming a dependency cycle, or nil.
+func (wf *Workflow) findCycle(index map[string]int) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(wf.Jobs))
+	var path []string
+
+	var visit func(i int) []string
+	visit = func(i int) []string {
+		state[i] = visiting
+		path = append(path, wf.Jobs[i].Name)
+		for _, need := range wf.Jobs[i].Needs {
+			n := index[need]
+			switch state[n] {
+			case visiting:
+				for k, name := range path {
+					if name == need {
+						return append(append([]string{}, path[k:]...), need)
+					}
+				}
+			case unvisited:
+				if cycle := visit(n); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = done
+		return nil
+	}
+
+	for i := range wf.Jobs {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// JobVars returns the workflow vars overridden by the job's own vars.
+func (wf *Workflow) JobVars(j Job) map[string]string {
+	vars := make(map[string]string, len(wf.Vars)+len(j.Vars))
+	for name, value := range wf.Vars {
+		vars[strings.ToUpper(name)] = value
+	}
+	for name, value := range j.Vars {
+		vars[strings.ToUpper(name)] = value
+	}
+	return vars
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "load.jcl"), []byte("//J JOB\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "wf.yaml")
+	src := `name: test
+max_parallel: 2
+vars:
+  env: TEST
+jobs:
+  - name: extract
+    jcl: MY.JCL(EXTRACT)
+    timeout: 10m
+  - name: load
+    jcl: load.jcl
+    needs: [extract]
+    max_rc: 8
+    vars:
+      ENV: PROD
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wf, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if wf.Jobs[0].JCL != "MY.JCL(EXTRACT)" || wf.Jobs[0].Timeout.Minutes() != 10 {
+		t.Errorf("extract = %+v", wf.Jobs[0])
+	}
+	if wf.Jobs[1].JCL != filepath.Join(dir, "load.jcl") {
+		t.Errorf("local JCL not resolved relative to workflow: %s", wf.Jobs[1].JCL)
+	}
+	if *wf.Jobs[1].MaxRC != 8 {
+		t.Errorf("max_rc = %d", *wf.Jobs[1].MaxRC)
+	}
+	if vars := wf.JobVars(wf.Jobs[1]); vars["ENV"] != "PROD" {
+		t.Errorf("job vars = %v", vars)
+	}
+	if vars := wf.JobVars(wf.Jobs[0]); vars["ENV"] != "TEST" {
+		t.Errorf("workflow vars = %v", vars)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobs    []Job
+		wantErr string
+	}{
+		{
+			name:    "no jobs",
+			wantErr: "no jobs",
+		},
+		{
+			name:    "duplicate name",
+			jobs:    []Job{{Name: "a", JCL: "X"}, {Name: "a", JCL: "Y"}},
+			wantErr: "duplicate",
+		},
+		{
+			name:    "unknown need",
+			jobs:    []Job{{Name: "a", JCL: "X", Needs: []string{"b"}}},
+			wantErr: "unknown job",
+		},
+		{
+			name:    "invalid condition",
+			jobs:    []Job{{Name: "a", JCL: "X", When: "sometimes"}},
+			wantErr: "invalid return code filter",
+		},
+		{
+			name: "cycle",
+			jobs: []Job{
+				{Name: "a", JCL: "X"},
+				{Name: "b", JCL: "X", Needs: []string{"a", "d"}},
+				{Name: "c", JCL: "X", Needs: []string{"b"}},
+				{Name: "d", JCL: "X", Needs: []string{"c"}},
+			},
+			wantErr: "dependency cycle: b -> d -> c -> b",
+		},
+		{
+			name: "valid",
+			jobs: []Job{
+				{Name: "a", JCL: "X"},
+				{Name: "b", JCL: "X", Needs: []string{"a"}, When: "<=8"},
+				{Name: "c", JCL: "X", Needs: []string{"a", "b"}, When: "failure"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &Workflow{Jobs: tt.jobs}
+			err := wf.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}